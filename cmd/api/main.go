@@ -10,6 +10,7 @@ import (
 	"github.com/gopro/internal/middleware"
 	"github.com/gopro/internal/db"
 	"github.com/gofiber/jwt/v3"
+	"github.com/hibiken/asynq"
 	"os"
 )
 
@@ -17,6 +18,8 @@ func main() {
 	cfg := config.LoadEnv()
 	rdb := redis.InitRedis(cfg)
 	pgdb := db.InitPostgres(cfg)
+	queue := asynq.NewClient(asynq.RedisClientOpt{Addr: cfg.RedisAddr})
+	defer queue.Close()
 
 	app := fiber.New(fiber.Config{
 		Prefork:       true,
@@ -31,7 +34,9 @@ func main() {
 	}))
 
 	app.Post("/auth/request", handlers.RequestOTP(rdb, pgdb))
-	app.Post("/auth/callback", handlers.OTPCallback())
+	app.Post("/auth/callback", handlers.OTPCallback(rdb))
+	app.Get("/preregistrations/:preregistration_id", handlers.VerifyPreregistration(pgdb))
+	app.Get("/invitations/:token", handlers.OpenInvitation(pgdb))
 
 	
 	secure := app.Group("/", jwtware.New(jwtware.Config{
@@ -41,6 +46,76 @@ func main() {
 	secure.Post("/create", handlers.CreatePoll(rdb, pgdb))
 	secure.Post("/poll/:poll_id", handlers.GetPoll(rdb, pgdb))
 	secure.Post("/vote/:poll_id", handlers.CastPoll(rdb, pgdb))
+	secure.Get("/polls/:poll_id/pair", handlers.GetPair(rdb, pgdb))
+	secure.Post("/polls/:poll_id/compare", handlers.ComparePair(rdb, pgdb))
+	secure.Get("/polls/:poll_id/ranking", handlers.GetPairwiseRanking(rdb, pgdb))
+	secure.Post("/polls/:poll_id/ranking/refresh", handlers.RefreshPairwiseRanking(pgdb, queue))
+	secure.Post("/polls/:poll_id/submissions", handlers.SubmitOption(pgdb))
+	secure.Get("/polls/:poll_id/submissions", handlers.ListSubmissions(pgdb))
+	secure.Post("/polls/:poll_id/submissions/:submission_id/review", handlers.ReviewSubmission(pgdb))
+	secure.Put("/polls/:poll_id/phase", handlers.SetPollPhase(pgdb))
+	secure.Get("/polls/:poll_id/write-ins", handlers.ListWriteIns(pgdb))
+	secure.Post("/polls/:poll_id/write-ins/promote", handlers.PromoteWriteIn(pgdb))
+	secure.Get("/polls/:poll_id/results", handlers.GetPollResults(pgdb))
+	secure.Get("/polls/:poll_id/timeline", handlers.GetPollTimeline(pgdb))
+	secure.Get("/polls/:poll_id/bayes", handlers.GetPollPosterior(pgdb))
+	secure.Get("/polls/:poll_id/influence", handlers.GetInfluenceResults(pgdb))
+	secure.Get("/polls/:poll_id/latency", handlers.GetPollLatency(pgdb))
+	secure.Get("/polls/:poll_id/privacy", handlers.GetPrivacyLedger(pgdb))
+	secure.Post("/polls/:poll_id/preregistration", handlers.PreregisterPoll(pgdb))
+	secure.Put("/polls/:poll_id/consent-text", handlers.SetConsentText(pgdb))
+	secure.Post("/polls/:poll_id/consent", handlers.GiveConsent(pgdb))
+	secure.Get("/polls/:poll_id/consents/export", handlers.ExportConsents(pgdb))
+	secure.Put("/polls/:poll_id/screeners", handlers.SetScreeners(pgdb))
+	secure.Get("/polls/:poll_id/screeners", handlers.GetScreeners(pgdb))
+	secure.Post("/polls/:poll_id/screening", handlers.SubmitScreening(pgdb))
+	secure.Get("/polls/:poll_id/screening/stats", handlers.GetScreeningStats(pgdb))
+	secure.Put("/polls/:poll_id/attention-check", handlers.SetAttentionCheck(pgdb))
+	secure.Post("/polls/:poll_id/share", handlers.CreateShareLink(pgdb))
+	secure.Get("/polls/:poll_id/cascade", handlers.GetPollCascade(pgdb))
+	secure.Post("/polls/:poll_id/invitations", handlers.CreateInvitations(pgdb, queue))
+	secure.Get("/polls/:poll_id/invitations", handlers.ListInvitations(pgdb))
+	secure.Post("/polls/:poll_id/invitations/reminders", handlers.ScheduleReminders(pgdb, queue))
+	secure.Post("/surveys", handlers.CreateSurvey(pgdb))
+	secure.Get("/surveys/:survey_id", handlers.GetSurvey(pgdb))
+	secure.Get("/surveys/:survey_id/response", handlers.GetSurveyResponse(pgdb))
+	secure.Put("/surveys/:survey_id/response", handlers.SaveSurveyResponse(pgdb))
+	secure.Post("/surveys/:survey_id/response/submit", handlers.SubmitSurveyResponse(pgdb))
+	secure.Get("/surveys/:survey_id/results", handlers.GetSurveyResults(pgdb))
+	secure.Get("/surveys/:survey_id/quality", handlers.GetSurveyQuality(pgdb))
+	secure.Get("/surveys/:survey_id/next", handlers.GetNextSurveyQuestion(pgdb))
+	secure.Post("/surveys/:survey_id/rules", handlers.CreateBranchRule(pgdb))
+	secure.Get("/surveys/:survey_id/rules", handlers.ListBranchRules(pgdb))
+	secure.Delete("/surveys/:survey_id/rules/:rule_id", handlers.DeleteBranchRule(pgdb))
+	secure.Post("/experiments", handlers.CreateExperiment(pgdb))
+	secure.Post("/experiments/power", handlers.ComputePower(pgdb))
+	secure.Get("/experiments/:experiment_id", handlers.GetExperiment(pgdb))
+	secure.Get("/experiments/:experiment_id/assignment", handlers.GetAssignment(pgdb))
+	secure.Get("/experiments/:experiment_id/results", handlers.GetExperimentResults(pgdb))
+	secure.Get("/experiments/:experiment_id/analysis", handlers.GetExperimentAnalysis(pgdb))
+	secure.Get("/experiments/:experiment_id/bayes", handlers.GetExperimentPosterior(pgdb))
+	secure.Get("/experiments/:experiment_id/balance", handlers.GetExperimentBalance(pgdb))
+	secure.Get("/experiments/:experiment_id/latency", handlers.GetExperimentLatency(pgdb))
+	secure.Post("/experiments/:experiment_id/preregistration", handlers.PreregisterExperiment(pgdb))
+	secure.Put("/experiments/:experiment_id/target", handlers.SetExperimentTarget(pgdb))
+	secure.Post("/conjoint", handlers.CreateConjointStudy(pgdb))
+	secure.Get("/conjoint/:study_id", handlers.GetConjointStudy(pgdb))
+	secure.Get("/conjoint/:study_id/task", handlers.GetConjointTask(pgdb))
+	secure.Post("/conjoint/:study_id/tasks/:task_number/choice", handlers.ChooseConjointProfile(pgdb))
+	secure.Post("/conjoint/:study_id/estimate", handlers.RefreshConjointEstimates(pgdb, queue))
+	secure.Get("/conjoint/:study_id/results", handlers.GetConjointResults(pgdb))
+	secure.Post("/panels", handlers.CreatePanel(pgdb))
+	secure.Get("/panels/:panel_id", handlers.GetPanel(pgdb))
+	secure.Post("/panels/:panel_id/members", handlers.AddPanelMembers(pgdb))
+	secure.Post("/panels/:panel_id/waves", handlers.CreateWave(pgdb, queue))
+	secure.Get("/panels/:panel_id/transitions", handlers.GetPanelTransitions(pgdb))
+	secure.Get("/panels/:panel_id/attrition", handlers.GetPanelAttrition(pgdb))
+	secure.Post("/recurring-polls", handlers.CreateRecurringPoll(pgdb))
+	secure.Get("/recurring-polls/:recurring_id", handlers.GetRecurringPoll(pgdb))
+	secure.Put("/recurring-polls/:recurring_id/active", handlers.SetRecurringPollActive(pgdb))
+	secure.Post("/recurring-polls/:recurring_id/subscription", handlers.SubscribeRecurringPoll(pgdb))
+	secure.Delete("/recurring-polls/:recurring_id/subscription", handlers.UnsubscribeRecurringPoll(pgdb))
+	secure.Get("/recurring-polls/:recurring_id/series", handlers.GetRecurringSeries(pgdb))
 	
 
 	app.Get(("polldata/:poll_id"), handlers.GetPollData(pgdb))
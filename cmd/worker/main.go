@@ -2,15 +2,20 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/gopro/internal/config"
+	"github.com/gopro/internal/db"
 	"github.com/gopro/internal/jobs"
 )
 
 func main() {
 	cfg := config.LoadEnv()
 	redisOpt := asynq.RedisClientOpt{Addr: cfg.RedisAddr}
+	pgdb := db.InitPostgres(cfg)
+	queue := asynq.NewClient(redisOpt)
+	defer queue.Close()
 
 	srv := asynq.NewServer(redisOpt, asynq.Config{
 		Concurrency: 10,
@@ -24,6 +29,26 @@ func main() {
 	mux := asynq.NewServeMux()
 	mux.HandleFunc("email:send_otp", jobs.HandleEmailTask)
 	mux.HandleFunc("sms:send_otp", jobs.HandleSMSTask)
+	mux.HandleFunc(jobs.TypePairwiseFit, jobs.HandlePairwiseFitTask(pgdb))
+	mux.HandleFunc(jobs.TypeConjointFit, jobs.HandleConjointFitTask(pgdb))
+	mux.HandleFunc(jobs.TypeMessage, jobs.HandleMessageTask)
+	mux.HandleFunc(jobs.TypeWaveInvitation, jobs.HandleWaveInvitationTask(pgdb, queue))
+	mux.HandleFunc(jobs.TypeRecurringPoll, jobs.HandleRecurringPollTask(pgdb, queue))
+	mux.HandleFunc(jobs.TypeInvitation, jobs.HandleInvitationTask(pgdb))
+
+	// Recurring poll schedules live in Postgres and are re-read every minute
+	scheduler, err := asynq.NewPeriodicTaskManager(asynq.PeriodicTaskManagerOpts{
+		RedisConnOpt:               redisOpt,
+		PeriodicTaskConfigProvider: jobs.RecurringPollConfigs{DB: pgdb},
+		SyncInterval:               time.Minute,
+	})
+	if err != nil {
+		log.Fatalf("Could not create scheduler: %v", err)
+	}
+	if err := scheduler.Start(); err != nil {
+		log.Fatalf("Could not start scheduler: %v", err)
+	}
+	defer scheduler.Shutdown()
 
 	if err := srv.Run(mux); err != nil {
 		log.Fatalf("Could not run worker server: %v", err)
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/stats"
+	"gorm.io/gorm"
+)
+
+type ContingencyTable struct {
+	Arms      []string    `json:"arms"`
+	Positions []int       `json:"positions"`
+	Counts    [][]float64 `json:"counts"`
+}
+
+type ArmComparison struct {
+	Position     int    `json:"option_position"`
+	OptionText   string `json:"option_text"`
+	ControlArm   string `json:"control_arm"`
+	TreatmentArm string `json:"treatment_arm"`
+	Significant  bool   `json:"significant"`
+	stats.ProportionTestResult
+}
+
+// armTallies returns the vote tally of every arm, in arm order.
+func armTallies(db *gorm.DB, arms []models.ExperimentArm) ([]PollResults, error) {
+	tallies := make([]PollResults, len(arms))
+	for i, arm := range arms {
+		tally, err := tallyPoll(db, arm.PollID)
+		if err != nil {
+			return nil, err
+		}
+		tallies[i] = tally
+	}
+	return tallies, nil
+}
+
+// contingencyTable lays out arm tallies as arms x option positions.
+func contingencyTable(arms []models.ExperimentArm, tallies []PollResults) ContingencyTable {
+	maxPos := -1
+	for _, t := range tallies {
+		for _, o := range t.Options {
+			if o.Position > maxPos {
+				maxPos = o.Position
+			}
+		}
+	}
+	table := ContingencyTable{
+		Arms:      make([]string, len(arms)),
+		Positions: make([]int, maxPos+1),
+		Counts:    make([][]float64, len(arms)),
+	}
+	for p := range table.Positions {
+		table.Positions[p] = p
+	}
+	for i, arm := range arms {
+		table.Arms[i] = arm.Name
+		table.Counts[i] = make([]float64, maxPos+1)
+		for _, o := range tallies[i].Options {
+			table.Counts[i][o.Position] += float64(o.Votes)
+		}
+	}
+	return table
+}
+
+// GetExperimentAnalysis runs a chi-square test of independence between arm
+// and chosen option, and two-proportion z-tests of every option's share in
+// each arm against the first (control) arm.
+func GetExperimentAnalysis(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		experiment, err := findExperiment(c, db)
+		if err != nil {
+			return err
+		}
+		alpha := c.QueryFloat("alpha", 0.05)
+		if alpha <= 0 || alpha >= 1 {
+			return fiber.NewError(fiber.StatusBadRequest, "alpha must be between 0 and 1")
+		}
+
+		arms, err := experimentArms(db, experiment.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+		}
+		tallies, err := armTallies(db, arms)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to tally votes")
+		}
+		table := contingencyTable(arms, tallies)
+
+		var chiSquare *fiber.Map
+		if res, err := stats.ChiSquareIndependence(table.Counts); err == nil {
+			chiSquare = &fiber.Map{
+				"statistic":   res.Statistic,
+				"df":          res.DF,
+				"p_value":     res.PValue,
+				"cramers_v":   res.CramersV,
+				"significant": res.PValue < alpha,
+			}
+		}
+
+		comparisons := []ArmComparison{}
+		if len(arms) > 0 {
+			control := tallies[0]
+			for i := 1; i < len(arms); i++ {
+				for _, o := range control.Options {
+					var treated float64
+					for _, t := range tallies[i].Options {
+						if t.Position == o.Position {
+							treated = float64(t.Votes)
+						}
+					}
+					res, err := stats.TwoProportionTest(
+						float64(o.Votes), float64(control.TotalVotes),
+						treated, float64(tallies[i].TotalVotes),
+						alpha,
+					)
+					if err != nil {
+						continue
+					}
+					comparisons = append(comparisons, ArmComparison{
+						Position:             o.Position,
+						OptionText:           o.OptionText,
+						ControlArm:           arms[0].Name,
+						TreatmentArm:         arms[i].Name,
+						Significant:          res.PValue < alpha,
+						ProportionTestResult: res,
+					})
+				}
+			}
+		}
+
+		return c.JSON(fiber.Map{
+			"experiment_id": experiment.ID,
+			"alpha":         alpha,
+			"table":         table,
+			"chi_square":    chiSquare,
+			"comparisons":   comparisons,
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gopro/internal/randomize"
+	"github.com/gopro/internal/stats"
+	"gorm.io/gorm"
+)
+
+const bayesDraws = 20000
+
+type OptionPosterior struct {
+	OptionID   string  `json:"option_id"`
+	OptionText string  `json:"option_text"`
+	Position   int     `json:"position"`
+	Votes      int64   `json:"votes"`
+	ProbLeader float64 `json:"prob_leader"`
+	stats.BetaSummary
+}
+
+type ArmPosterior struct {
+	Arm              string  `json:"arm"`
+	Votes            int64   `json:"votes"`
+	Total            int64   `json:"total"`
+	ProbBest         float64 `json:"prob_best"`
+	ProbBeatsControl float64 `json:"prob_beats_control"`
+	stats.BetaSummary
+}
+
+func credibleLevel(c *fiber.Ctx) (float64, error) {
+	level := c.QueryFloat("level", 0.95)
+	if level <= 0 || level >= 1 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "level must be between 0 and 1")
+	}
+	return level, nil
+}
+
+// GetPollPosterior summarizes a poll's results under a uniform Dirichlet
+// prior: a credible interval for each option's share and the probability
+// that each option is the true leader.
+func GetPollPosterior(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findPoll(c, db)
+		if err != nil {
+			return err
+		}
+		if err := guardTallies(c, poll); err != nil {
+			return err
+		}
+		level, err := credibleLevel(c)
+		if err != nil {
+			return err
+		}
+		tally, err := tallyPoll(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to tally votes")
+		}
+		if len(tally.Options) == 0 {
+			return fiber.NewError(fiber.StatusConflict, "Poll has no options")
+		}
+
+		alpha := make([]float64, len(tally.Options))
+		var alpha0 float64
+		for i, o := range tally.Options {
+			alpha[i] = 1 + float64(o.Votes)
+			alpha0 += alpha[i]
+		}
+		// Seeding from the data keeps the estimate stable between requests
+		// until new votes arrive.
+		r := rand.New(rand.NewPCG(randomize.Seed(poll.ID.String(), strconv.FormatInt(tally.TotalVotes, 10)), 0))
+		leader := stats.DirichletLeaderProbabilities(r, alpha, bayesDraws)
+
+		posteriors := make([]OptionPosterior, len(tally.Options))
+		for i, o := range tally.Options {
+			posteriors[i] = OptionPosterior{
+				OptionID:    o.OptionID,
+				OptionText:  o.OptionText,
+				Position:    o.Position,
+				Votes:       o.Votes,
+				ProbLeader:  leader[i],
+				BetaSummary: stats.SummarizeBeta(alpha[i], alpha0-alpha[i], level),
+			}
+		}
+
+		return c.JSON(fiber.Map{
+			"poll_id":     poll.ID,
+			"total_votes": tally.TotalVotes,
+			"level":       level,
+			"prior":       "uniform Dirichlet",
+			"options":     posteriors,
+		})
+	}
+}
+
+// GetExperimentPosterior compares arms on the share choosing one option
+// position, using independent Beta(1, 1) priors. The experiment can be
+// stopped once one arm's probability of being best reaches the threshold.
+func GetExperimentPosterior(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		experiment, err := findExperiment(c, db)
+		if err != nil {
+			return err
+		}
+		level, err := credibleLevel(c)
+		if err != nil {
+			return err
+		}
+		position := c.QueryInt("option", 0)
+		threshold := c.QueryFloat("threshold", 0.95)
+		if threshold <= 0 || threshold >= 1 {
+			return fiber.NewError(fiber.StatusBadRequest, "threshold must be between 0 and 1")
+		}
+
+		arms, err := experimentArms(db, experiment.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+		}
+		if len(arms) < 2 {
+			return fiber.NewError(fiber.StatusConflict, "Experiment needs at least 2 arms")
+		}
+		tallies, err := armTallies(db, arms)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to tally votes")
+		}
+
+		a := make([]float64, len(arms))
+		b := make([]float64, len(arms))
+		posteriors := make([]ArmPosterior, len(arms))
+		seed := experiment.ID.String() + ":" + strconv.Itoa(position)
+		for i, t := range tallies {
+			var votes int64
+			for _, o := range t.Options {
+				if o.Position == position {
+					votes = o.Votes
+				}
+			}
+			a[i] = 1 + float64(votes)
+			b[i] = 1 + float64(t.TotalVotes-votes)
+			posteriors[i] = ArmPosterior{
+				Arm:         arms[i].Name,
+				Votes:       votes,
+				Total:       t.TotalVotes,
+				BetaSummary: stats.SummarizeBeta(a[i], b[i], level),
+			}
+			seed += ":" + strconv.FormatInt(t.TotalVotes, 10)
+		}
+
+		r := rand.New(rand.NewPCG(randomize.Seed(seed), 0))
+		best, beats := stats.BetaBestProbabilities(r, a, b, bayesDraws)
+		top := 0
+		for i := range posteriors {
+			posteriors[i].ProbBest = best[i]
+			posteriors[i].ProbBeatsControl = beats[i]
+			if best[i] > best[top] {
+				top = i
+			}
+		}
+
+		return c.JSON(fiber.Map{
+			"experiment_id":   experiment.ID,
+			"option_position": position,
+			"level":           level,
+			"prior":           "Beta(1, 1)",
+			"arms":            posteriors,
+			"decision": fiber.Map{
+				"threshold": threshold,
+				"best_arm":  arms[top].Name,
+				"prob_best": best[top],
+				"stop":      best[top] >= threshold,
+			},
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"gorm.io/gorm"
+)
+
+// surveyPlan holds everything needed to decide which questions a
+// participant should see given their answers so far.
+type surveyPlan struct {
+	questions []models.SurveyQuestion
+	rules     []models.BranchRule
+	scores    map[uuid.UUID]float64 // numeric value of options whose text is a number
+}
+
+// surveyWalk is the outcome of walking a survey plan against a set of answers.
+type surveyWalk struct {
+	path     []uuid.UUID // questions the participant is shown, in order
+	onPath   map[uuid.UUID]bool
+	next     *uuid.UUID // first shown question without an answer
+	complete bool       // every shown question is answered
+}
+
+func loadSurveyPlan(db *gorm.DB, surveyID uuid.UUID) (*surveyPlan, error) {
+	questions, err := surveyQuestions(db, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	plan := &surveyPlan{questions: questions, scores: make(map[uuid.UUID]float64)}
+	if err := db.Where("survey_id = ?", surveyID).Order("created_at").Find(&plan.rules).Error; err != nil {
+		return nil, err
+	}
+	if len(plan.rules) == 0 {
+		return plan, nil
+	}
+
+	pollIDs := make([]uuid.UUID, len(questions))
+	for i, q := range questions {
+		pollIDs[i] = q.PollID
+	}
+	var options []models.PollOption
+	if err := db.Where("poll_id IN ?", pollIDs).Find(&options).Error; err != nil {
+		return nil, err
+	}
+	for _, opt := range options {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(opt.OptionText), 64); err == nil {
+			plan.scores[opt.ID] = v
+		}
+	}
+	return plan, nil
+}
+
+func (p *surveyPlan) position(pollID uuid.UUID) int {
+	for i, q := range p.questions {
+		if q.PollID == pollID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *surveyPlan) matches(rule models.BranchRule, optionID uuid.UUID) bool {
+	switch rule.Operator {
+	case "equals":
+		return rule.OptionID != nil && *rule.OptionID == optionID
+	case "score_gte", "score_lte":
+		score, ok := p.scores[optionID]
+		if !ok || rule.Threshold == nil {
+			return false
+		}
+		if rule.Operator == "score_gte" {
+			return score >= *rule.Threshold
+		}
+		return score <= *rule.Threshold
+	}
+	return false
+}
+
+// visible reports whether a question is shown. known is false when a
+// show_if condition depends on a question that has not been answered yet.
+func (p *surveyPlan) visible(pollID uuid.UUID, answers map[uuid.UUID]uuid.UUID) (shown, known bool) {
+	for _, rule := range p.rules {
+		if rule.Action != "show_if" || rule.TargetPollID != pollID {
+			continue
+		}
+		optionID, answered := answers[rule.SourcePollID]
+		if !answered {
+			return false, false
+		}
+		if !p.matches(rule, optionID) {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+func (p *surveyPlan) hasSkipRules(pollID uuid.UUID) bool {
+	for _, rule := range p.rules {
+		if rule.Action == "skip_to" && rule.SourcePollID == pollID {
+			return true
+		}
+	}
+	return false
+}
+
+// walk follows the survey from the first question, applying show_if and
+// skip_to rules. Unanswered questions without skip rules are stepped over so
+// participants may still answer out of order; the walk stops once the path
+// depends on an answer that has not been given.
+func (p *surveyPlan) walk(answers map[uuid.UUID]uuid.UUID) surveyWalk {
+	w := surveyWalk{onPath: make(map[uuid.UUID]bool)}
+	pos := 0
+	for pos < len(p.questions) {
+		pollID := p.questions[pos].PollID
+		shown, known := p.visible(pollID, answers)
+		if !known {
+			break
+		}
+		if !shown {
+			pos++
+			continue
+		}
+		w.path = append(w.path, pollID)
+		w.onPath[pollID] = true
+
+		optionID, answered := answers[pollID]
+		if !answered {
+			if w.next == nil {
+				next := pollID
+				w.next = &next
+			}
+			if p.hasSkipRules(pollID) {
+				break
+			}
+			pos++
+			continue
+		}
+
+		jump := pos + 1
+		for _, rule := range p.rules {
+			if rule.Action == "skip_to" && rule.SourcePollID == pollID && p.matches(rule, optionID) {
+				if target := p.position(rule.TargetPollID); target > pos {
+					jump = target
+				}
+				break
+			}
+		}
+		pos = jump
+	}
+	w.complete = w.next == nil && pos >= len(p.questions)
+	return w
+}
+
+func findOwnedSurvey(c *fiber.Ctx, db *gorm.DB) (models.Survey, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return models.Survey{}, err
+	}
+	survey, err := findSurvey(c, db)
+	if err != nil {
+		return survey, err
+	}
+	if survey.CreatedBy != userID.String() {
+		return survey, fiber.NewError(fiber.StatusForbidden, "Only the survey owner can do this")
+	}
+	return survey, nil
+}
+
+// CreateBranchRule adds a skip or display rule to a survey.
+func CreateBranchRule(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		survey, err := findOwnedSurvey(c, db)
+		if err != nil {
+			return err
+		}
+
+		var req struct {
+			SourcePollID string   `json:"source_poll_id"`
+			Operator     string   `json:"operator"` // "equals", "score_gte" or "score_lte"
+			OptionID     string   `json:"option_id"`
+			Threshold    *float64 `json:"threshold"`
+			Action       string   `json:"action"` // "skip_to" or "show_if"
+			TargetPollID string   `json:"target_poll_id"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		sourceID, err := uuid.Parse(req.SourcePollID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid source_poll_id")
+		}
+		targetID, err := uuid.Parse(req.TargetPollID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid target_poll_id")
+		}
+		if req.Action != "skip_to" && req.Action != "show_if" {
+			return fiber.NewError(fiber.StatusBadRequest, "Action must be skip_to or show_if")
+		}
+
+		plan, err := loadSurveyPlan(db, survey.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to load survey")
+		}
+		sourcePos, targetPos := plan.position(sourceID), plan.position(targetID)
+		if sourcePos < 0 || targetPos < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Questions do not belong to survey")
+		}
+		if targetPos <= sourcePos {
+			return fiber.NewError(fiber.StatusBadRequest, "Target question must come after the source question")
+		}
+
+		rule := models.BranchRule{
+			ID:           uuid.New(),
+			SurveyID:     survey.ID,
+			SourcePollID: sourceID,
+			Operator:     req.Operator,
+			Action:       req.Action,
+			TargetPollID: targetID,
+			CreatedAt:    time.Now(),
+		}
+		switch req.Operator {
+		case "equals":
+			optionID, err := uuid.Parse(req.OptionID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid option_id")
+			}
+			var count int64
+			if err := db.Model(&models.PollOption{}).Where("id = ? AND poll_id = ?", optionID, sourceID).Count(&count).Error; err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to check option")
+			}
+			if count == 0 {
+				return fiber.NewError(fiber.StatusBadRequest, "Option does not belong to source question")
+			}
+			rule.OptionID = &optionID
+		case "score_gte", "score_lte":
+			if req.Threshold == nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Threshold required for score rules")
+			}
+			rule.Threshold = req.Threshold
+		default:
+			return fiber.NewError(fiber.StatusBadRequest, "Operator must be equals, score_gte or score_lte")
+		}
+
+		if err := db.Create(&rule).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create rule")
+		}
+		return c.Status(fiber.StatusCreated).JSON(rule)
+	}
+}
+
+// ListBranchRules returns a survey's rules in evaluation order.
+func ListBranchRules(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		survey, err := findOwnedSurvey(c, db)
+		if err != nil {
+			return err
+		}
+		var rules []models.BranchRule
+		if err := db.Where("survey_id = ?", survey.ID).Order("created_at").Find(&rules).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch rules")
+		}
+		return c.JSON(rules)
+	}
+}
+
+// DeleteBranchRule removes a rule from a survey.
+func DeleteBranchRule(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		survey, err := findOwnedSurvey(c, db)
+		if err != nil {
+			return err
+		}
+		ruleID, err := uuid.Parse(c.Params("rule_id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid rule_id")
+		}
+		res := db.Where("id = ? AND survey_id = ?", ruleID, survey.ID).Delete(&models.BranchRule{})
+		if res.Error != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete rule")
+		}
+		if res.RowsAffected == 0 {
+			return fiber.NewError(fiber.StatusNotFound, "Rule not found")
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// GetNextSurveyQuestion computes the next question the caller should answer
+// from their saved answers.
+func GetNextSurveyQuestion(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		survey, err := findSurvey(c, db)
+		if err != nil {
+			return err
+		}
+		plan, err := loadSurveyPlan(db, survey.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to load survey")
+		}
+		_, answers, err := loadSurveyResponse(db, survey.ID, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch response")
+		}
+
+		w := plan.walk(answerMap(answers))
+		if w.next == nil {
+			return c.JSON(fiber.Map{"survey_id": survey.ID, "complete": w.complete, "next": nil})
+		}
+
+		var poll models.Poll
+		if err := db.Where("id = ?", *w.next).First(&poll).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch question")
+		}
+		options, err := pollOptions(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch question options")
+		}
+		next := SurveyQuestionView{
+			PollID:      poll.ID.String(),
+			Position:    plan.position(poll.ID),
+			Title:       poll.Title,
+			Description: poll.Description,
+			Options:     make([]OptionView, 0, len(options)),
+		}
+		for _, opt := range options {
+			next.Options = append(next.Options, OptionView{ID: opt.ID.String(), Text: opt.OptionText})
+		}
+		return c.JSON(fiber.Map{"survey_id": survey.ID, "complete": false, "next": next})
+	}
+}
+
+func answerMap(answers []models.SurveyAnswer) map[uuid.UUID]uuid.UUID {
+	m := make(map[uuid.UUID]uuid.UUID, len(answers))
+	for _, a := range answers {
+		m[a.PollID] = a.OptionID
+	}
+	return m
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+)
+
+// branchingSurvey is a three-question survey: q1 is yes/no, q2 is a scale
+// whose options score 1 and 5, and q3 has a single option.
+type branchingSurvey struct {
+	q1, q2, q3     uuid.UUID
+	yes, no        uuid.UUID
+	low, high, any uuid.UUID
+}
+
+func newBranchingSurvey() branchingSurvey {
+	return branchingSurvey{
+		q1: uuid.New(), q2: uuid.New(), q3: uuid.New(),
+		yes: uuid.New(), no: uuid.New(),
+		low: uuid.New(), high: uuid.New(), any: uuid.New(),
+	}
+}
+
+func (s branchingSurvey) plan(rules ...models.BranchRule) *surveyPlan {
+	return &surveyPlan{
+		questions: []models.SurveyQuestion{
+			{PollID: s.q1, Position: 0},
+			{PollID: s.q2, Position: 1},
+			{PollID: s.q3, Position: 2},
+		},
+		rules:  rules,
+		scores: map[uuid.UUID]float64{s.low: 1, s.high: 5},
+	}
+}
+
+func checkWalk(t *testing.T, w surveyWalk, path []uuid.UUID, next *uuid.UUID, complete bool) {
+	t.Helper()
+	if !slices.Equal(w.path, path) {
+		t.Errorf("path = %v, want %v", w.path, path)
+	}
+	for _, id := range path {
+		if !w.onPath[id] {
+			t.Errorf("onPath misses %v", id)
+		}
+	}
+	if (w.next == nil) != (next == nil) || (w.next != nil && *w.next != *next) {
+		t.Errorf("next = %v, want %v", w.next, next)
+	}
+	if w.complete != complete {
+		t.Errorf("complete = %v, want %v", w.complete, complete)
+	}
+}
+
+func TestSurveyWalkWithoutRules(t *testing.T) {
+	s := newBranchingSurvey()
+	plan := s.plan()
+	all := []uuid.UUID{s.q1, s.q2, s.q3}
+
+	checkWalk(t, plan.walk(nil), all, &s.q1, false)
+	// Answering ahead does not move next past an earlier unanswered question.
+	checkWalk(t, plan.walk(map[uuid.UUID]uuid.UUID{s.q2: s.low}), all, &s.q1, false)
+	checkWalk(t, plan.walk(map[uuid.UUID]uuid.UUID{s.q1: s.yes, s.q2: s.low, s.q3: s.any}), all, nil, true)
+}
+
+func TestSurveyWalkSkipTo(t *testing.T) {
+	s := newBranchingSurvey()
+	plan := s.plan(models.BranchRule{SourcePollID: s.q1, Operator: "equals", OptionID: &s.no, Action: "skip_to", TargetPollID: s.q3})
+
+	// The rest of the path is unknown until q1 is answered.
+	checkWalk(t, plan.walk(nil), []uuid.UUID{s.q1}, &s.q1, false)
+	checkWalk(t, plan.walk(map[uuid.UUID]uuid.UUID{s.q1: s.no}), []uuid.UUID{s.q1, s.q3}, &s.q3, false)
+	checkWalk(t, plan.walk(map[uuid.UUID]uuid.UUID{s.q1: s.yes, s.q2: s.low, s.q3: s.any}),
+		[]uuid.UUID{s.q1, s.q2, s.q3}, nil, true)
+}
+
+func TestSurveyWalkShowIf(t *testing.T) {
+	s := newBranchingSurvey()
+	threshold := 4.0
+	plan := s.plan(models.BranchRule{SourcePollID: s.q2, Operator: "score_gte", Threshold: &threshold, Action: "show_if", TargetPollID: s.q3})
+
+	checkWalk(t, plan.walk(map[uuid.UUID]uuid.UUID{s.q1: s.yes}), []uuid.UUID{s.q1, s.q2}, &s.q2, false)
+	checkWalk(t, plan.walk(map[uuid.UUID]uuid.UUID{s.q1: s.yes, s.q2: s.low}), []uuid.UUID{s.q1, s.q2}, nil, true)
+	checkWalk(t, plan.walk(map[uuid.UUID]uuid.UUID{s.q1: s.yes, s.q2: s.high}), []uuid.UUID{s.q1, s.q2, s.q3}, &s.q3, false)
+}
@@ -0,0 +1,528 @@
+package handlers
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/jobs"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/randomize"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultConjointTasks = 5
+
+// maxFractionSize bounds the profiles of a fractional design, which are
+// drawn once and stored with the study.
+const maxFractionSize = 5000
+
+type ConjointAttributeView struct {
+	Name   string   `json:"name"`
+	Levels []string `json:"levels"`
+}
+
+type ConjointProfileView struct {
+	Profile    int               `json:"profile"` // 0 or 1, the value to submit as choice
+	Attributes map[string]string `json:"attributes"`
+}
+
+// conjointDesign holds a study's attributes and, per attribute, its levels,
+// both in display order.
+type conjointDesign struct {
+	attributes []models.ConjointAttribute
+	levels     [][]models.ConjointLevel
+}
+
+func findConjointStudy(c *fiber.Ctx, db *gorm.DB) (models.ConjointStudy, error) {
+	var study models.ConjointStudy
+	studyID, err := uuid.Parse(c.Params("study_id"))
+	if err != nil {
+		return study, fiber.NewError(fiber.StatusBadRequest, "Invalid study_id")
+	}
+	if err := db.Where("id = ?", studyID).First(&study).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return study, fiber.NewError(fiber.StatusNotFound, "Study not found")
+		}
+		return study, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve study")
+	}
+	return study, nil
+}
+
+func loadConjointDesign(db *gorm.DB, studyID uuid.UUID) (conjointDesign, error) {
+	var design conjointDesign
+	if err := db.Where("study_id = ?", studyID).Order("position").Find(&design.attributes).Error; err != nil {
+		return design, err
+	}
+	design.levels = make([][]models.ConjointLevel, len(design.attributes))
+	for i, attr := range design.attributes {
+		if err := db.Where("attribute_id = ?", attr.ID).Order("position").Find(&design.levels[i]).Error; err != nil {
+			return design, err
+		}
+	}
+	return design, nil
+}
+
+// profileCount is the size of the full factorial, capped at 1<<40 so it
+// cannot overflow. It is only compared against fraction sizes and reported;
+// profiles are never addressed by their index in it.
+func profileCount(sizes []int) int {
+	n := 1
+	for _, s := range sizes {
+		n *= s
+		if n > 1<<40 {
+			return 1 << 40
+		}
+	}
+	return n
+}
+
+// fractionProfiles picks n distinct profiles of the full factorial, each
+// as one level index per attribute. Small factorials (at most 4n profiles)
+// are enumerated and shuffled; in larger ones every attribute's level is
+// drawn independently and the rare repeated profile is redrawn.
+func fractionProfiles(r *rand.Rand, sizes []int, n int) [][]int {
+	profiles := make([][]int, 0, n)
+	if total := profileCount(sizes); total <= 4*n {
+		for _, index := range r.Perm(total)[:n] {
+			profile := make([]int, len(sizes))
+			for i := len(sizes) - 1; i >= 0; i-- {
+				profile[i] = index % sizes[i]
+				index /= sizes[i]
+			}
+			profiles = append(profiles, profile)
+		}
+		return profiles
+	}
+	seen := make(map[string]bool, n)
+	for len(profiles) < n {
+		profile := make([]int, len(sizes))
+		for i, s := range sizes {
+			profile[i] = r.IntN(s)
+		}
+		key := profileKey(profile)
+		if !seen[key] {
+			seen[key] = true
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// profileKey joins a profile's level indices with commas.
+func profileKey(profile []int) string {
+	parts := make([]string, len(profile))
+	for i, level := range profile {
+		parts[i] = strconv.Itoa(level)
+	}
+	return strings.Join(parts, ",")
+}
+
+// encodeFraction stores a fractional design as profiles separated by ";",
+// each a comma-separated list of level indices.
+func encodeFraction(profiles [][]int) string {
+	parts := make([]string, len(profiles))
+	for i, profile := range profiles {
+		parts[i] = profileKey(profile)
+	}
+	return strings.Join(parts, ";")
+}
+
+// decodeFraction parses encodeFraction's output, skipping profiles that do
+// not have one valid level per attribute.
+func decodeFraction(s string, sizes []int) [][]int {
+	if s == "" {
+		return nil
+	}
+	var profiles [][]int
+	for _, part := range strings.Split(s, ";") {
+		levels := strings.Split(part, ",")
+		if len(levels) != len(sizes) {
+			continue
+		}
+		profile := make([]int, len(sizes))
+		valid := true
+		for i, l := range levels {
+			level, err := strconv.Atoi(l)
+			if err != nil || level < 0 || level >= sizes[i] {
+				valid = false
+				break
+			}
+			profile[i] = level
+		}
+		if valid {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// taskProfiles draws the two distinct profiles of a respondent's task. The
+// draw is seeded by study, respondent and task number, so concurrent
+// requests generate the same task.
+func taskProfiles(study models.ConjointStudy, design conjointDesign, userID uuid.UUID, taskNumber int) ([]int, []int) {
+	sizes := make([]int, len(design.levels))
+	for i, levels := range design.levels {
+		sizes[i] = len(levels)
+	}
+	r := rand.New(rand.NewPCG(randomize.Seed(study.ID.String(), userID.String(), strconv.Itoa(taskNumber)), 0))
+
+	var fraction [][]int
+	if study.Design == "fractional" {
+		fraction = decodeFraction(study.Fraction, sizes)
+	}
+	draw := func() []int {
+		if len(fraction) > 1 {
+			return fraction[r.IntN(len(fraction))]
+		}
+		profile := make([]int, len(sizes))
+		for i, s := range sizes {
+			profile[i] = r.IntN(s)
+		}
+		return profile
+	}
+	a := draw()
+	for {
+		b := draw()
+		for i := range a {
+			if a[i] != b[i] {
+				return a, b
+			}
+		}
+	}
+}
+
+func encodeProfile(design conjointDesign, profile []int) string {
+	ids := make([]string, len(profile))
+	for i, level := range profile {
+		ids[i] = design.levels[i][level].ID.String()
+	}
+	return strings.Join(ids, ",")
+}
+
+func profileView(design conjointDesign, index int, encoded string) ConjointProfileView {
+	labels := make(map[string]string)
+	for _, levels := range design.levels {
+		for _, level := range levels {
+			labels[level.ID.String()] = level.Label
+		}
+	}
+	view := ConjointProfileView{Profile: index, Attributes: make(map[string]string, len(design.attributes))}
+	for i, id := range strings.Split(encoded, ",") {
+		if i < len(design.attributes) {
+			view.Attributes[design.attributes[i].Name] = labels[id]
+		}
+	}
+	return view
+}
+
+func CreateConjointStudy(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+
+		var req struct {
+			Title              string                  `json:"title"`
+			Description        string                  `json:"description"`
+			Design             string                  `json:"design"` // "full" (default) or "fractional"
+			FractionSize       int                     `json:"fraction_size"`
+			TasksPerRespondent int                     `json:"tasks_per_respondent"`
+			Attributes         []ConjointAttributeView `json:"attributes"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		if req.Title == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Title required")
+		}
+		if len(req.Attributes) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "At least 1 attribute required")
+		}
+		if req.Design == "" {
+			req.Design = "full"
+		}
+		if req.Design != "full" && req.Design != "fractional" {
+			return fiber.NewError(fiber.StatusBadRequest, "Design must be full or fractional")
+		}
+		if req.TasksPerRespondent == 0 {
+			req.TasksPerRespondent = defaultConjointTasks
+		}
+		if req.TasksPerRespondent < 1 {
+			return fiber.NewError(fiber.StatusBadRequest, "tasks_per_respondent must be positive")
+		}
+
+		study := models.ConjointStudy{
+			ID:                 uuid.New(),
+			Title:              req.Title,
+			Description:        req.Description,
+			CreatedBy:          userID.String(),
+			CreatedAt:          time.Now(),
+			Design:             req.Design,
+			TasksPerRespondent: req.TasksPerRespondent,
+		}
+		var attributes []models.ConjointAttribute
+		var levels []models.ConjointLevel
+		sizes := make([]int, len(req.Attributes))
+		names := make(map[string]bool, len(req.Attributes))
+		for i, a := range req.Attributes {
+			if a.Name == "" || names[a.Name] {
+				return fiber.NewError(fiber.StatusBadRequest, "Attribute names must be unique and non-empty")
+			}
+			names[a.Name] = true
+			if len(a.Levels) < 2 {
+				return fiber.NewError(fiber.StatusBadRequest, "Attribute "+a.Name+" needs at least 2 levels")
+			}
+			attr := models.ConjointAttribute{ID: uuid.New(), StudyID: study.ID, Name: a.Name, Position: i}
+			attributes = append(attributes, attr)
+			for j, label := range a.Levels {
+				levels = append(levels, models.ConjointLevel{ID: uuid.New(), AttributeID: attr.ID, Label: label, Position: j})
+			}
+			sizes[i] = len(a.Levels)
+		}
+		if study.Design == "fractional" {
+			study.FractionSize = req.FractionSize
+			if study.FractionSize < 2 || study.FractionSize >= profileCount(sizes) {
+				return fiber.NewError(fiber.StatusBadRequest, "fraction_size must be at least 2 and smaller than the full factorial")
+			}
+			if study.FractionSize > maxFractionSize {
+				return fiber.NewError(fiber.StatusBadRequest, "fraction_size must be at most "+strconv.Itoa(maxFractionSize))
+			}
+			r := rand.New(rand.NewPCG(randomize.Seed(study.ID.String(), "fraction"), 0))
+			study.Fraction = encodeFraction(fractionProfiles(r, sizes, study.FractionSize))
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&study).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&attributes).Error; err != nil {
+				return err
+			}
+			return tx.Create(&levels).Error
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create study")
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"study_id":             study.ID,
+			"title":                study.Title,
+			"design":               study.Design,
+			"fraction_size":        study.FractionSize,
+			"full_factorial_size":  profileCount(sizes),
+			"tasks_per_respondent": study.TasksPerRespondent,
+			"attributes":           req.Attributes,
+		})
+	}
+}
+
+func GetConjointStudy(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		study, err := findConjointStudy(c, db)
+		if err != nil {
+			return err
+		}
+		design, err := loadConjointDesign(db, study.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch attributes")
+		}
+		attributes := make([]ConjointAttributeView, len(design.attributes))
+		for i, attr := range design.attributes {
+			attributes[i] = ConjointAttributeView{Name: attr.Name, Levels: make([]string, len(design.levels[i]))}
+			for j, level := range design.levels[i] {
+				attributes[i].Levels[j] = level.Label
+			}
+		}
+		return c.JSON(fiber.Map{
+			"study_id":             study.ID,
+			"title":                study.Title,
+			"description":          study.Description,
+			"design":               study.Design,
+			"fraction_size":        study.FractionSize,
+			"tasks_per_respondent": study.TasksPerRespondent,
+			"attributes":           attributes,
+		})
+	}
+}
+
+// GetConjointTask returns the respondent's next unanswered choice task,
+// generating it on first request.
+func GetConjointTask(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		study, err := findConjointStudy(c, db)
+		if err != nil {
+			return err
+		}
+		design, err := loadConjointDesign(db, study.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch attributes")
+		}
+
+		var answered int64
+		err = db.Model(&models.ConjointTask{}).
+			Where("study_id = ? AND user_id = ? AND chosen IS NOT NULL", study.ID, userID).
+			Count(&answered).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch tasks")
+		}
+		if int(answered) >= study.TasksPerRespondent {
+			return c.JSON(fiber.Map{"study_id": study.ID, "complete": true, "answered": answered})
+		}
+
+		// Tasks are answered in order, so the next one is numbered answered.
+		a, b := taskProfiles(study, design, userID, int(answered))
+		task := models.ConjointTask{
+			ID:         uuid.New(),
+			StudyID:    study.ID,
+			UserID:     userID,
+			TaskNumber: int(answered),
+			ProfileA:   encodeProfile(design, a),
+			ProfileB:   encodeProfile(design, b),
+			CreatedAt:  time.Now(),
+		}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&task).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create task")
+		}
+		err = db.Where("study_id = ? AND user_id = ? AND task_number = ?", study.ID, userID, task.TaskNumber).First(&task).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch task")
+		}
+
+		return c.JSON(fiber.Map{
+			"study_id":    study.ID,
+			"complete":    false,
+			"task_number": task.TaskNumber,
+			"total_tasks": study.TasksPerRespondent,
+			"profiles": []ConjointProfileView{
+				profileView(design, 0, task.ProfileA),
+				profileView(design, 1, task.ProfileB),
+			},
+		})
+	}
+}
+
+func ChooseConjointProfile(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		study, err := findConjointStudy(c, db)
+		if err != nil {
+			return err
+		}
+		taskNumber, err := strconv.Atoi(c.Params("task_number"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid task_number")
+		}
+
+		var req struct {
+			Profile *int `json:"profile"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		if req.Profile == nil || (*req.Profile != 0 && *req.Profile != 1) {
+			return fiber.NewError(fiber.StatusBadRequest, "profile must be 0 or 1")
+		}
+
+		now := time.Now()
+		res := db.Model(&models.ConjointTask{}).
+			Where("study_id = ? AND user_id = ? AND task_number = ? AND chosen IS NULL", study.ID, userID, taskNumber).
+			Updates(map[string]interface{}{"chosen": *req.Profile, "answered_at": now})
+		if res.Error != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to record choice")
+		}
+		if res.RowsAffected == 0 {
+			return fiber.NewError(fiber.StatusConflict, "Task not found or already answered")
+		}
+		return c.JSON(fiber.Map{"message": "Choice recorded", "task_number": taskNumber})
+	}
+}
+
+// RefreshConjointEstimates queues the worker job that fits AMCEs.
+func RefreshConjointEstimates(db *gorm.DB, queue *asynq.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		study, err := findConjointStudy(c, db)
+		if err != nil {
+			return err
+		}
+		if study.CreatedBy != userID.String() {
+			return fiber.NewError(fiber.StatusForbidden, "Only the study owner can do this")
+		}
+
+		_, err = queue.Enqueue(jobs.NewConjointFitTask(study.ID.String()), asynq.Unique(time.Minute))
+		if err != nil && err != asynq.ErrDuplicateTask {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to queue estimation")
+		}
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "Estimation queued"})
+	}
+}
+
+// GetConjointResults returns the latest AMCE table, one row per level.
+func GetConjointResults(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		study, err := findConjointStudy(c, db)
+		if err != nil {
+			return err
+		}
+		design, err := loadConjointDesign(db, study.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch attributes")
+		}
+		var estimates []models.ConjointEstimate
+		if err := db.Where("study_id = ?", study.ID).Find(&estimates).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch estimates")
+		}
+		if len(estimates) == 0 {
+			return fiber.NewError(fiber.StatusNotFound, "No estimates yet; POST /conjoint/:study_id/estimate first")
+		}
+		byLevel := make(map[uuid.UUID]models.ConjointEstimate, len(estimates))
+		for _, e := range estimates {
+			byLevel[e.LevelID] = e
+		}
+
+		rows := make([]fiber.Map, 0, len(estimates))
+		for i, attr := range design.attributes {
+			for _, level := range design.levels[i] {
+				e, ok := byLevel[level.ID]
+				if !ok {
+					continue
+				}
+				rows = append(rows, fiber.Map{
+					"attribute": attr.Name,
+					"level":     level.Label,
+					"baseline":  e.Baseline,
+					"amce":      e.Estimate,
+					"std_error": e.StdErr,
+					"ci_lower":  e.Lower,
+					"ci_upper":  e.Upper,
+				})
+			}
+		}
+		first := estimates[0]
+		return c.JSON(fiber.Map{
+			"study_id":     study.ID,
+			"model":        "linear probability, respondent-clustered standard errors",
+			"observations": first.Observations,
+			"respondents":  first.Respondents,
+			"computed_at":  first.ComputedAt,
+			"estimates":    rows,
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestFractionProfiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		sizes []int
+		n     int
+	}{
+		{"enumerated small factorial", []int{2, 2, 3}, 10},
+		{"enumerated at the threshold", []int{20, 20}, 100},
+		{"sampled large factorial", []int{10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10}, maxFractionSize},
+	}
+	for _, tt := range tests {
+		profiles := fractionProfiles(rand.New(rand.NewPCG(1, 2)), tt.sizes, tt.n)
+		if len(profiles) != tt.n {
+			t.Fatalf("%s: got %d profiles, want %d", tt.name, len(profiles), tt.n)
+		}
+		seen := make(map[string]bool, len(profiles))
+		for _, p := range profiles {
+			key := profileKey(p)
+			if seen[key] {
+				t.Fatalf("%s: profile %v drawn twice", tt.name, p)
+			}
+			seen[key] = true
+		}
+		decoded := decodeFraction(encodeFraction(profiles), tt.sizes)
+		if !slices.EqualFunc(decoded, profiles, slices.Equal[[]int]) {
+			t.Errorf("%s: fraction does not round-trip through its encoding", tt.name)
+		}
+	}
+}
+
+func TestFractionProfilesCoverEveryLevel(t *testing.T) {
+	// The full factorial is 10^14 profiles, far beyond what an index into it
+	// can address without a cap; every attribute, including the first, must
+	// still use all of its levels at about the same rate.
+	sizes := make([]int, 14)
+	for i := range sizes {
+		sizes[i] = 10
+	}
+	profiles := fractionProfiles(rand.New(rand.NewPCG(3, 4)), sizes, 2000)
+	for attr := range sizes {
+		counts := make([]int, 10)
+		for _, p := range profiles {
+			counts[p[attr]]++
+		}
+		for level, c := range counts {
+			if c < 120 || c > 280 {
+				t.Errorf("attribute %d level %d appears %d times in 2000 profiles", attr, level, c)
+			}
+		}
+	}
+}
+
+func TestDecodeFractionSkipsInvalidProfiles(t *testing.T) {
+	got := decodeFraction("0,1;2,0;1;x,0;1,1", []int{2, 2})
+	want := [][]int{{0, 1}, {1, 1}}
+	if !slices.EqualFunc(got, want, slices.Equal[[]int]) {
+		t.Errorf("decodeFraction = %v, want %v", got, want)
+	}
+}
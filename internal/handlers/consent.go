@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ConsentView struct {
+	Version  int    `json:"version"`
+	Text     string `json:"text"`
+	Accepted bool   `json:"accepted"` // by the requesting participant
+}
+
+// currentConsentText returns the poll's latest consent text, or nil if the
+// poll does not require consent.
+func currentConsentText(db *gorm.DB, pollID uuid.UUID) (*models.ConsentText, error) {
+	var text models.ConsentText
+	err := db.Where("poll_id = ?", pollID).Order("version DESC").First(&text).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &text, nil
+}
+
+func hasConsented(db *gorm.DB, text models.ConsentText, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Model(&models.Consent{}).
+		Where("consent_text_id = ? AND user_id = ?", text.ID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SetConsentText publishes a new version of the poll's consent text.
+// Participants must accept the new version before voting.
+func SetConsentText(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			Text string `json:"text"`
+		}
+		if err := c.BodyParser(&req); err != nil || req.Text == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Text required")
+		}
+
+		current, err := currentConsentText(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch consent text")
+		}
+		text := models.ConsentText{
+			ID:        uuid.New(),
+			PollID:    poll.ID,
+			Version:   1,
+			Text:      req.Text,
+			CreatedAt: time.Now(),
+		}
+		if current != nil {
+			text.Version = current.Version + 1
+		}
+		if err := db.Create(&text).Error; err != nil {
+			return fiber.NewError(fiber.StatusConflict, "Consent text was updated concurrently; try again")
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"poll_id": poll.ID,
+			"version": text.Version,
+			"text":    text.Text,
+		})
+	}
+}
+
+// GiveConsent records the participant's acceptance of the current consent
+// text. The version must match, so nobody accepts a text they were not shown.
+func GiveConsent(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		poll, err := findPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			Version int `json:"version"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+
+		text, err := currentConsentText(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch consent text")
+		}
+		if text == nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Poll does not require consent")
+		}
+		if req.Version != text.Version {
+			return fiber.NewError(fiber.StatusConflict, "Consent text has changed; fetch the poll again")
+		}
+
+		consent := models.Consent{
+			ID:            uuid.New(),
+			PollID:        poll.ID,
+			UserID:        userID,
+			ConsentTextID: text.ID,
+			Version:       text.Version,
+			AcceptedAt:    time.Now(),
+		}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&consent).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to record consent")
+		}
+		return c.JSON(fiber.Map{"message": "Consent recorded", "version": text.Version})
+	}
+}
+
+// ExportConsents returns every recorded consent as CSV for ethics review.
+// Each row carries the SHA-256 of the exact text accepted.
+func ExportConsents(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var texts []models.ConsentText
+		if err := db.Where("poll_id = ?", poll.ID).Find(&texts).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch consent texts")
+		}
+		digests := make(map[uuid.UUID]string, len(texts))
+		for _, t := range texts {
+			sum := sha256.Sum256([]byte(t.Text))
+			digests[t.ID] = hex.EncodeToString(sum[:])
+		}
+		var consents []models.Consent
+		if err := db.Where("poll_id = ?", poll.ID).Order("accepted_at").Find(&consents).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch consents")
+		}
+
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"poll_id", "user_id", "version", "accepted_at", "text_sha256"})
+		for _, consent := range consents {
+			w.Write([]string{
+				poll.ID.String(),
+				consent.UserID.String(),
+				strconv.Itoa(consent.Version),
+				consent.AcceptedAt.UTC().Format(time.RFC3339),
+				digests[consent.ConsentTextID],
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to write export")
+		}
+
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="consents-`+poll.ID.String()+`.csv"`)
+		return c.Send(buf.Bytes())
+	}
+}
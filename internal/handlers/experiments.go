@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/randomize"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ArmView struct {
+	ArmID  string  `json:"arm_id"`
+	Name   string  `json:"name"`
+	PollID string  `json:"poll_id"`
+	Weight float64 `json:"weight"`
+}
+
+type ArmResults struct {
+	ArmView
+	Assigned int64       `json:"assigned"`
+	Results  PollResults `json:"results"`
+}
+
+func findExperiment(c *fiber.Ctx, db *gorm.DB) (models.Experiment, error) {
+	var experiment models.Experiment
+	experimentID, err := uuid.Parse(c.Params("experiment_id"))
+	if err != nil {
+		return experiment, fiber.NewError(fiber.StatusBadRequest, "Invalid experiment_id")
+	}
+	if err := db.Where("id = ?", experimentID).First(&experiment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return experiment, fiber.NewError(fiber.StatusNotFound, "Experiment not found")
+		}
+		return experiment, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve experiment")
+	}
+	return experiment, nil
+}
+
+func findOwnedExperiment(c *fiber.Ctx, db *gorm.DB) (models.Experiment, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return models.Experiment{}, err
+	}
+	experiment, err := findExperiment(c, db)
+	if err != nil {
+		return experiment, err
+	}
+	if experiment.CreatedBy != userID.String() {
+		return experiment, fiber.NewError(fiber.StatusForbidden, "Only the experiment owner can do this")
+	}
+	return experiment, nil
+}
+
+// experimentArms returns an experiment's arms in the order they were defined.
+func experimentArms(db *gorm.DB, experimentID uuid.UUID) ([]models.ExperimentArm, error) {
+	var arms []models.ExperimentArm
+	err := db.Where("experiment_id = ?", experimentID).Order("position").Find(&arms).Error
+	return arms, err
+}
+
+// armForPoll returns the experiment arm a poll belongs to, or nil if the
+// poll is not part of an experiment.
+func armForPoll(db *gorm.DB, pollID uuid.UUID) (*models.ExperimentArm, error) {
+	var arm models.ExperimentArm
+	err := db.Where("poll_id = ?", pollID).First(&arm).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &arm, nil
+}
+
+// assignArm returns the participant's arm, assigning one on first contact.
+// Hash randomization is a deterministic hash of experiment and user, so the
+// same participant always lands in the same arm even if the record is lost.
+// Block randomization draws from the participant's stratum block instead.
+// attrs carries self-reported attributes used for stratification.
+func assignArm(db *gorm.DB, experiment models.Experiment, arms []models.ExperimentArm, userID uuid.UUID, attrs map[string]string) (models.ExperimentArm, error) {
+	var assignment models.ExperimentAssignment
+	err := db.Where("experiment_id = ? AND user_id = ?", experiment.ID, userID).First(&assignment).Error
+	if err == gorm.ErrRecordNotFound {
+		assignment, err = newAssignment(db, experiment, arms, userID, attrs)
+	}
+	if err != nil {
+		return models.ExperimentArm{}, err
+	}
+
+	for _, arm := range arms {
+		if arm.ID == assignment.ArmID {
+			return arm, nil
+		}
+	}
+	return models.ExperimentArm{}, gorm.ErrRecordNotFound
+}
+
+func newAssignment(db *gorm.DB, experiment models.Experiment, arms []models.ExperimentArm, userID uuid.UUID, attrs map[string]string) (models.ExperimentAssignment, error) {
+	stratum, err := stratumFor(db, experiment, userID, attrs)
+	if err != nil {
+		return models.ExperimentAssignment{}, err
+	}
+	if experiment.Randomization == "block" {
+		return assignFromBlock(db, experiment, arms, userID, stratum)
+	}
+
+	weights := make([]float64, len(arms))
+	for i, arm := range arms {
+		weights[i] = arm.Weight
+	}
+	arm := arms[randomize.Weighted(randomize.Unit(experiment.ID.String(), userID.String()), weights)]
+	assignment := models.ExperimentAssignment{
+		ID:           uuid.New(),
+		ExperimentID: experiment.ID,
+		ArmID:        arm.ID,
+		UserID:       userID,
+		Stratum:      stratum,
+		AssignedAt:   time.Now(),
+	}
+	// A concurrent request may have assigned the user first; keep theirs.
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignment)
+	if res.Error != nil {
+		return assignment, res.Error
+	}
+	if res.RowsAffected == 0 {
+		err = db.Where("experiment_id = ? AND user_id = ?", experiment.ID, userID).First(&assignment).Error
+	}
+	return assignment, err
+}
+
+func armView(arm models.ExperimentArm) ArmView {
+	return ArmView{
+		ArmID:  arm.ID.String(),
+		Name:   arm.Name,
+		PollID: arm.PollID.String(),
+		Weight: arm.Weight,
+	}
+}
+
+// CreateExperiment groups existing polls owned by the caller into an
+// experiment, one arm per poll variant.
+func CreateExperiment(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+
+		var req struct {
+			Title         string   `json:"title"`
+			Description   string   `json:"description"`
+			Randomization string   `json:"randomization"` // "hash" (default) or "block"
+			BlockSize     int      `json:"block_size"`
+			StratifyBy    []string `json:"stratify_by"` // "country", "age_group"
+			Arms          []struct {
+				Name   string  `json:"name"`
+				PollID string  `json:"poll_id"`
+				Weight float64 `json:"weight"`
+			} `json:"arms"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		if len(req.Arms) < 2 {
+			return fiber.NewError(fiber.StatusBadRequest, "At least 2 arms required")
+		}
+
+		if req.Randomization == "" {
+			req.Randomization = "hash"
+		}
+		if req.Randomization != "hash" && req.Randomization != "block" {
+			return fiber.NewError(fiber.StatusBadRequest, "Randomization must be hash or block")
+		}
+		for _, key := range req.StratifyBy {
+			if key != "country" && key != "age_group" {
+				return fiber.NewError(fiber.StatusBadRequest, "Can only stratify by country or age_group")
+			}
+		}
+
+		experiment := models.Experiment{
+			ID:            uuid.New(),
+			Title:         req.Title,
+			Description:   req.Description,
+			CreatedBy:     userID.String(),
+			CreatedAt:     time.Now(),
+			Randomization: req.Randomization,
+			StratifyBy:    strings.Join(req.StratifyBy, ","),
+		}
+		arms := make([]models.ExperimentArm, len(req.Arms))
+		seen := make(map[uuid.UUID]bool, len(req.Arms))
+		for i, a := range req.Arms {
+			pollID, err := uuid.Parse(a.PollID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid poll_id")
+			}
+			if seen[pollID] {
+				return fiber.NewError(fiber.StatusBadRequest, "Each arm needs its own poll")
+			}
+			seen[pollID] = true
+
+			var poll models.Poll
+			if err := db.Where("id = ?", pollID).First(&poll).Error; err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Poll not found: "+a.PollID)
+			}
+			if poll.CreatedBy != userID.String() {
+				return fiber.NewError(fiber.StatusForbidden, "Arms must use polls you created")
+			}
+			if poll.Mode != "standard" || poll.SurveyID != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Arms must use standard polls")
+			}
+			// Arm tallies are compared exactly, which would spend no budget
+			if poll.PrivacyBudget > 0 {
+				return fiber.NewError(fiber.StatusBadRequest, "Arms cannot use polls with differentially private results")
+			}
+			// Arm results would show tallies to participants kept blind
+			if len(influenceConditions(poll)) > 0 {
+				return fiber.NewError(fiber.StatusBadRequest, "Arms cannot use polls that randomize result visibility")
+			}
+			existing, err := armForPoll(db, pollID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to check poll")
+			}
+			if existing != nil {
+				return fiber.NewError(fiber.StatusConflict, "Poll is already part of an experiment")
+			}
+
+			weight := a.Weight
+			if weight == 0 {
+				weight = 1
+			}
+			if weight < 0 {
+				return fiber.NewError(fiber.StatusBadRequest, "Arm weights must be positive")
+			}
+			name := a.Name
+			if name == "" {
+				name = string(rune('A' + i))
+			}
+			arms[i] = models.ExperimentArm{
+				ID:           uuid.New(),
+				ExperimentID: experiment.ID,
+				PollID:       pollID,
+				Name:         name,
+				Weight:       weight,
+				Position:     i,
+			}
+		}
+
+		if experiment.Randomization == "block" {
+			experiment.BlockSize = req.BlockSize
+			if experiment.BlockSize == 0 {
+				// Two full cycles of the (integer) weights per block.
+				for _, arm := range arms {
+					experiment.BlockSize += 2 * int(math.Round(arm.Weight))
+				}
+			}
+			if _, err := blockComposition(arms, experiment.BlockSize); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&experiment).Error; err != nil {
+				return err
+			}
+			return tx.Create(&arms).Error
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create experiment")
+		}
+
+		views := make([]ArmView, len(arms))
+		for i, arm := range arms {
+			views[i] = armView(arm)
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"experiment_id":  experiment.ID,
+			"title":          experiment.Title,
+			"description":    experiment.Description,
+			"created_at":     experiment.CreatedAt,
+			"created_by":     experiment.CreatedBy,
+			"randomization":  experiment.Randomization,
+			"block_size":     experiment.BlockSize,
+			"stratify_by":    stratifyKeys(experiment),
+			"target_per_arm": experiment.TargetPerArm,
+			"arms":           views,
+		})
+	}
+}
+
+// GetExperiment returns an experiment and its arms.
+func GetExperiment(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		experiment, err := findExperiment(c, db)
+		if err != nil {
+			return err
+		}
+		arms, err := experimentArms(db, experiment.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+		}
+		views := make([]ArmView, len(arms))
+		for i, arm := range arms {
+			views[i] = armView(arm)
+		}
+		return c.JSON(fiber.Map{
+			"experiment_id": experiment.ID,
+			"title":         experiment.Title,
+			"description":   experiment.Description,
+			"created_at":    experiment.CreatedAt,
+			"created_by":    experiment.CreatedBy,
+			"randomization": experiment.Randomization,
+			"block_size":    experiment.BlockSize,
+			"stratify_by":   stratifyKeys(experiment),
+			"arms":          views,
+		})
+	}
+}
+
+// GetAssignment assigns the caller to an arm if needed and returns the
+// variant poll they should be shown.
+func GetAssignment(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		experiment, err := findExperiment(c, db)
+		if err != nil {
+			return err
+		}
+		arms, err := experimentArms(db, experiment.ID)
+		if err != nil || len(arms) == 0 {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+		}
+
+		attrs := map[string]string{"age_group": c.Query("age_group")}
+		arm, err := assignArm(db, experiment, arms, userID, attrs)
+		if err != nil {
+			if fe, ok := err.(*fiber.Error); ok {
+				return fe
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to assign arm")
+		}
+		return c.JSON(fiber.Map{
+			"experiment_id": experiment.ID,
+			"arm":           armView(arm),
+			"poll_url":      c.BaseURL() + "/poll/" + arm.PollID.String(),
+		})
+	}
+}
+
+// GetExperimentResults reports assignment counts and vote tallies per arm.
+// Options are listed by position so the same slot can be compared across
+// variants that word or order things differently.
+func GetExperimentResults(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		experiment, err := findExperiment(c, db)
+		if err != nil {
+			return err
+		}
+		arms, err := experimentArms(db, experiment.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+		}
+
+		results := make([]ArmResults, 0, len(arms))
+		for _, arm := range arms {
+			var assigned int64
+			if err := db.Model(&models.ExperimentAssignment{}).Where("arm_id = ?", arm.ID).Count(&assigned).Error; err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to count assignments")
+			}
+			excluded, err := excludedRespondents(c, db, []uuid.UUID{arm.PollID}, nil)
+			if err != nil {
+				return err
+			}
+			tally, err := tallyPollExcluding(db, arm.PollID, excluded)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to tally votes")
+			}
+			if excluded != nil {
+				n := len(excluded)
+				tally.ExcludedRespondents = &n
+			}
+			results = append(results, ArmResults{ArmView: armView(arm), Assigned: assigned, Results: tally})
+		}
+
+		return c.JSON(fiber.Map{
+			"experiment_id": experiment.ID,
+			"arms":          results,
+		})
+	}
+}
@@ -12,18 +12,39 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/privacy"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Poll struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Options     []string  `json:"options"`
-	CreatedAt   time.Time `json:"created_at"`
-	PublicURL   string    `json:"public_url"`
-	CreatedBy   string    `json:"created_by"`
+	ID            string       `json:"id"`
+	Title         string       `json:"title"`
+	Description   string       `json:"description"`
+	Options       []string     `json:"options"`
+	CreatedAt     time.Time    `json:"created_at"`
+	PublicURL     string       `json:"public_url"`
+	CreatedBy     string       `json:"created_by"`
+	Mode          string       `json:"mode"`
+	Phase         string       `json:"phase"`
+	OptionDetails []OptionView `json:"option_details"`
+	Condition     string       `json:"condition,omitempty"`
+	Shuffled      bool         `json:"shuffled"` // options are in this respondent's random order
+	RespondBy     *time.Time   `json:"respond_by,omitempty"`
+	// Instruction is this respondent's randomized response draw: "truthful",
+	// "forced_yes" or "forced_no". It is the same on every fetch.
+	Instruction string       `json:"instruction,omitempty"`
+	ClosedAt    *time.Time   `json:"closed_at,omitempty"`
+	Consent     *ConsentView `json:"consent,omitempty"` // must be accepted before voting
+}
+
+type OptionView struct {
+	ID          string  `json:"id"`
+	Text        string  `json:"text"`
+	SubmittedBy *string `json:"submitted_by,omitempty"`
+	WriteIn     bool    `json:"write_in,omitempty"`
+	Votes       *int64  `json:"votes,omitempty"` // only for participants allowed to see tallies
 }
 
 func CreatePoll(rdb *redis.Client, db *gorm.DB) fiber.Handler {
@@ -41,48 +62,187 @@ func CreatePoll(rdb *redis.Client, db *gorm.DB) fiber.Handler {
 			Title       string   `json:"title"`
 			Description string   `json:"description"`
 			Options     []string `json:"options"`
+			Mode        string   `json:"mode"`
+			Phase       string   `json:"phase"`
+			// WriteInOption adds an extra "Other"-style option that accepts free text.
+			WriteInOption string `json:"write_in_option"`
+			// InfluenceConditions randomizes whether voters see tallies first:
+			// any of "visible", "seeded" and "blind".
+			InfluenceConditions []string `json:"influence_conditions"`
+			SeedCounts          []int    `json:"seed_counts"` // per option, for "seeded"
+			// ShuffleOptions shows options in a per-respondent random order.
+			ShuffleOptions bool `json:"shuffle_options"`
+			// MaxResponseSeconds limits the time from first fetch to vote.
+			MaxResponseSeconds int `json:"max_response_seconds"`
+			// Randomized response design; options must be ["yes", "no"] in that order.
+			TruthProbability     *float64 `json:"truth_probability"`
+			ForcedYesProbability *float64 `json:"forced_yes_probability"`
+			// Privacy enables differentially private results for non-owners.
+			Privacy *struct {
+				Budget    float64 `json:"budget"`
+				Epsilon   float64 `json:"epsilon"` // spent per release
+				Delta     float64 `json:"delta"`   // gaussian only
+				Mechanism string  `json:"mechanism"`
+			} `json:"privacy"`
 		}
 		if err := c.BodyParser(&req); err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
 		}
-		if len(req.Options) < 2 {
+		if req.Phase == "" {
+			req.Phase = "voting"
+		}
+		if req.Phase != "voting" && req.Phase != "submission" {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid phase")
+		}
+		// Polls that collect ideas first may start with no options at all.
+		if req.Phase == "voting" && len(req.Options) < 2 {
 			return fiber.NewError(fiber.StatusBadRequest, "At least 2 options required")
 		}
+		if req.Mode == "" {
+			req.Mode = "standard"
+		}
+		if req.Mode != "standard" && req.Mode != "pairwise" && req.Mode != "randomized_response" {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid mode")
+		}
+		truthProb, forcedYesProb, instructionKey := 0.0, 0.0, ""
+		if req.Mode == "randomized_response" {
+			if req.Phase != "voting" || len(req.Options) != 2 || req.WriteInOption != "" ||
+				!strings.EqualFold(strings.TrimSpace(req.Options[0]), "yes") ||
+				!strings.EqualFold(strings.TrimSpace(req.Options[1]), "no") {
+				return fiber.NewError(fiber.StatusBadRequest, "Randomized response polls need exactly 2 options: yes, then no")
+			}
+			truthProb, forcedYesProb = defaultTruthProbability, defaultForcedYesProbability
+			if req.TruthProbability != nil {
+				truthProb = *req.TruthProbability
+			}
+			if req.ForcedYesProbability != nil {
+				forcedYesProb = *req.ForcedYesProbability
+			}
+			if truthProb <= 0 || truthProb >= 1 {
+				return fiber.NewError(fiber.StatusBadRequest, "truth_probability must be between 0 and 1")
+			}
+			if forcedYesProb < 0 || forcedYesProb > 1 {
+				return fiber.NewError(fiber.StatusBadRequest, "forced_yes_probability must be between 0 and 1")
+			}
+			key, err := newInstructionKey()
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to create poll")
+			}
+			instructionKey = key
+		}
+		if req.MaxResponseSeconds < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "max_response_seconds must not be negative")
+		}
+		if len(req.InfluenceConditions) == 1 {
+			return fiber.NewError(fiber.StatusBadRequest, "At least 2 influence conditions required")
+		}
+		seenConditions := map[string]bool{}
+		for _, cond := range req.InfluenceConditions {
+			if !influenceConditionNames[cond] || seenConditions[cond] {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid influence condition: "+cond)
+			}
+			seenConditions[cond] = true
+		}
+		if len(req.SeedCounts) > 0 && len(req.SeedCounts) != len(req.Options) {
+			return fiber.NewError(fiber.StatusBadRequest, "seed_counts must have one entry per option")
+		}
+		if req.Privacy != nil {
+			// Influence conditions show exact counts to voters, which would
+			// bypass the budget.
+			if len(req.InfluenceConditions) > 0 {
+				return fiber.NewError(fiber.StatusBadRequest, "Privacy budgets cannot be combined with influence conditions")
+			}
+			// The pairwise ranking reports exact ratings and comparison counts.
+			if req.Mode == "pairwise" {
+				return fiber.NewError(fiber.StatusBadRequest, "Pairwise polls cannot use privacy budgets")
+			}
+			if req.Privacy.Mechanism == "" {
+				req.Privacy.Mechanism = privacy.Laplace
+			}
+			if req.Privacy.Budget <= 0 || req.Privacy.Epsilon > req.Privacy.Budget {
+				return fiber.NewError(fiber.StatusBadRequest, "Privacy budget must be positive and at least epsilon")
+			}
+			if err := privacy.Validate(req.Privacy.Mechanism, req.Privacy.Epsilon, req.Privacy.Delta); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid privacy parameters; gaussian needs epsilon < 1 and 0 < delta < 1")
+			}
+		}
 
 		pollID := uuid.New()
 		poll := models.Poll{
-			ID:            pollID,
-			WebsiteID:     req.PollName,
-			Title:         req.Title,
-			Description:   req.Description,
-			CreatedBy:     userIDStr,
-			CreatedAt:     time.Now(),
-			ShareableLink: c.BaseURL() + "/poll/" + pollID.String(),
+			ID:                   pollID,
+			WebsiteID:            req.PollName,
+			Title:                req.Title,
+			Description:          req.Description,
+			CreatedBy:            userIDStr,
+			CreatedAt:            time.Now(),
+			ShareableLink:        c.BaseURL() + "/poll/" + pollID.String(),
+			Mode:                 req.Mode,
+			Phase:                req.Phase,
+			InfluenceConditions:  strings.Join(req.InfluenceConditions, ","),
+			ShuffleOptions:       req.ShuffleOptions,
+			MaxResponseSeconds:   req.MaxResponseSeconds,
+			TruthProbability:     truthProb,
+			ForcedYesProbability: forcedYesProb,
+			InstructionKey:       instructionKey,
+		}
+		if req.Privacy != nil {
+			poll.PrivacyBudget = req.Privacy.Budget
+			poll.PrivacyEpsilon = req.Privacy.Epsilon
+			poll.PrivacyDelta = req.Privacy.Delta
+			poll.PrivacyMechanism = req.Privacy.Mechanism
 		}
 		if err := db.Create(&poll).Error; err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create poll")
 		}
 
-		for _, opt := range req.Options {
+		for i, opt := range req.Options {
 			option := models.PollOption{
 				ID:         uuid.New(),
 				PollID:     pollID,
 				OptionText: opt,
+				Position:   i,
+			}
+			if len(req.SeedCounts) > 0 {
+				option.SeedCount = req.SeedCounts[i]
+			}
+			db.Create(&option)
+		}
+		if req.WriteInOption != "" {
+			option := models.PollOption{
+				ID:         uuid.New(),
+				PollID:     pollID,
+				OptionText: req.WriteInOption,
+				WriteIn:    true,
+				Position:   len(req.Options),
 			}
 			db.Create(&option)
 		}
 
 		publicURL := c.BaseURL() + "/poll/" + pollID.String()
-		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-			"poll_id":     pollID,
-			"public_url":  publicURL,
-			"title":       poll.Title,
-			"created_at":  poll.CreatedAt,
-			"description": poll.Description,
-			"options":     req.Options,
-			"created_by":  userIDStr,
-			"poll_name":   req.PollName,
-		})
+		resp := fiber.Map{
+			"poll_id":              pollID,
+			"public_url":           publicURL,
+			"title":                poll.Title,
+			"created_at":           poll.CreatedAt,
+			"description":          poll.Description,
+			"options":              req.Options,
+			"created_by":           userIDStr,
+			"poll_name":            req.PollName,
+			"mode":                 poll.Mode,
+			"phase":                poll.Phase,
+			"write_in_option":      req.WriteInOption,
+			"influence_conditions": req.InfluenceConditions,
+			"shuffle_options":      poll.ShuffleOptions,
+			"max_response_seconds": poll.MaxResponseSeconds,
+		}
+		if poll.Mode == "randomized_response" {
+			resp["truth_probability"] = poll.TruthProbability
+			resp["forced_yes_probability"] = poll.ForcedYesProbability
+		}
+		if req.Privacy != nil {
+			resp["privacy"] = req.Privacy
+		}
+		return c.Status(fiber.StatusCreated).JSON(resp)
 	}
 }
 
@@ -113,11 +273,79 @@ func GetPoll(rdb *redis.Client, db *gorm.DB) fiber.Handler {
 
 		publicURL := c.BaseURL() + "/poll/" + poll.ID.String()
 		// Fetch options for the poll
-		var options []string
-		if err := db.Model(&models.PollOption{}).Where("poll_id = ?", poll.ID).Pluck("option_text", &options).Error; err != nil {
+		optionRows, err := pollOptions(db, poll.ID)
+		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch poll options")
 		}
 
+		// Polls studying social influence randomize whether tallies are shown;
+		// blind participants must never receive counts.
+		var condition string
+		var counts map[uuid.UUID]int64
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		// The first fetch starts the participant's response time and
+		// attributes them to the share link they followed, if any
+		referredBy, err := referrer(db, poll.ID, userID, c.Query("ref"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check share link")
+		}
+		view, err := recordView(db, poll.ID, userID, referredBy)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to record view")
+		}
+		var respondBy *time.Time
+		if poll.MaxResponseSeconds > 0 {
+			deadline := view.FirstFetchedAt.Add(time.Duration(poll.MaxResponseSeconds) * time.Second)
+			respondBy = &deadline
+		}
+		var consent *ConsentView
+		consentText, err := currentConsentText(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch consent text")
+		}
+		if consentText != nil {
+			accepted, err := hasConsented(db, *consentText, userID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to check consent")
+			}
+			consent = &ConsentView{Version: consentText.Version, Text: consentText.Text, Accepted: accepted}
+		}
+
+		var instruction string
+		if poll.Mode == "randomized_response" {
+			instruction = drawInstruction(poll, userID)
+		}
+		if len(influenceConditions(poll)) > 0 {
+			exposure, err := exposeResults(db, poll, userID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to assign condition")
+			}
+			condition = exposure.Condition
+			counts, err = shownCounts(db, condition, optionRows)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to tally votes")
+			}
+		}
+
+		options := make([]string, 0, len(optionRows))
+		details := make([]OptionView, 0, len(optionRows))
+		for _, opt := range displayOrder(poll, userID, optionRows) {
+			options = append(options, opt.OptionText)
+			view := OptionView{ID: opt.ID.String(), Text: opt.OptionText, WriteIn: opt.WriteIn}
+			if opt.SubmittedBy != nil {
+				submittedBy := opt.SubmittedBy.String()
+				view.SubmittedBy = &submittedBy
+			}
+			if counts != nil {
+				votes := counts[opt.ID]
+				view.Votes = &votes
+			}
+			details = append(details, view)
+		}
+
 		return c.JSON(Poll{
 			ID:          poll.ID.String(),
 			Title:       poll.Title,
@@ -126,6 +354,15 @@ func GetPoll(rdb *redis.Client, db *gorm.DB) fiber.Handler {
 			CreatedAt:   poll.CreatedAt,
 			PublicURL:   publicURL,
 			CreatedBy:   poll.CreatedBy,
+			Mode:          poll.Mode,
+			Phase:         poll.Phase,
+			OptionDetails: details,
+			Condition:     condition,
+			Shuffled:      poll.ShuffleOptions,
+			RespondBy:     respondBy,
+			Instruction:   instruction,
+			ClosedAt:      poll.ClosedAt,
+			Consent:       consent,
 		})
 	}
 }
@@ -156,6 +393,81 @@ func GetPollData(db *gorm.DB) fiber.Handler {
 	}
 }
 
+// checkVoter enforces the per-poll participation rules shared by direct
+// votes and survey submissions: the poll must be open, and the voter must
+// pass its screeners, belong to its panel wave and have given consent.
+func checkVoter(db *gorm.DB, poll models.Poll, userID uuid.UUID) error {
+	if poll.ClosedAt != nil {
+		return fiber.NewError(fiber.StatusConflict, "Poll is closed")
+	}
+	if err := checkEligible(db, poll.ID, userID); err != nil {
+		return err
+	}
+
+	// Panel waves are answered only by members, once the wave opens
+	wave, err := waveForPoll(db, poll.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to check panel wave")
+	}
+	if wave != nil {
+		if err := checkWaveVoter(db, *wave, userID); err != nil {
+			return err
+		}
+	}
+
+	// Research polls require documented consent to the current text
+	consentText, err := currentConsentText(db, poll.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to check consent")
+	}
+	if consentText != nil {
+		accepted, err := hasConsented(db, *consentText, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check consent")
+		}
+		if !accepted {
+			return fiber.NewError(fiber.StatusForbidden, "Consent required; accept it via /polls/:poll_id/consent")
+		}
+	}
+	return nil
+}
+
+// recordVote stores a vote inside tx along with its side effects: closing
+// the poll at its target sample, marking the voter's invitation and locking
+// preregistered plans.
+func recordVote(tx *gorm.DB, poll models.Poll, vote *models.Vote, experimentID *uuid.UUID) error {
+	// Polls that close at a target sample are locked so concurrent
+	// votes cannot overshoot it.
+	if poll.MaxVotes > 0 {
+		var locked models.Poll
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", poll.ID).First(&locked).Error; err != nil {
+			return err
+		}
+		if locked.ClosedAt != nil {
+			return fiber.NewError(fiber.StatusConflict, "Poll is closed")
+		}
+	}
+	if err := tx.Create(vote).Error; err != nil {
+		return err
+	}
+	if poll.MaxVotes > 0 {
+		var count int64
+		if err := tx.Model(&models.Vote{}).Where("poll_id = ?", poll.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) >= poll.MaxVotes {
+			if err := tx.Model(&models.Poll{}).Where("id = ?", poll.ID).Update("closed_at", vote.VotedAt).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if err := markInvitationVoted(tx, poll.ID, vote.UserID, vote.VotedAt); err != nil {
+		return err
+	}
+	// The first vote fixes any preregistered plan
+	return lockPreregistrations(tx, poll.ID, experimentID, vote.VotedAt)
+}
+
 func CastPoll(rdb *redis.Client, db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userID := c.Locals("user_id")
@@ -167,17 +479,29 @@ func CastPoll(rdb *redis.Client, db *gorm.DB) fiber.Handler {
 			return fiber.ErrUnauthorized
 		}
 
-		pollIDStr := c.Params("poll_id")
-		if pollIDStr == "" {
-			return fiber.NewError(fiber.StatusBadRequest, "Missing poll_id")
-		}
-		pollID, err := uuid.Parse(pollIDStr)
+		poll, err := findPoll(c, db)
 		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, "Invalid poll_id")
+			return err
 		}
+		if poll.Mode == "pairwise" {
+			return fiber.NewError(fiber.StatusBadRequest, "Pairwise polls are voted through /polls/:poll_id/compare")
+		}
+		if poll.Phase == "submission" {
+			return fiber.NewError(fiber.StatusConflict, "Poll is still collecting options")
+		}
+		if poll.SurveyID != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Survey questions are answered through /surveys/:survey_id/response")
+		}
+		pollID := poll.ID
 
 		var req struct {
 			OptionID string `json:"option_id"`
+			Text     string `json:"text"` // free text for write-in options
+			Paradata struct {
+				// SelectionChanges counts how often the participant changed
+				// their selection before submitting.
+				SelectionChanges *int `json:"selection_changes"`
+			} `json:"paradata"`
 		}
 		if err := c.BodyParser(&req); err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
@@ -186,12 +510,25 @@ func CastPoll(rdb *redis.Client, db *gorm.DB) fiber.Handler {
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, "Invalid option_id")
 		}
+		if req.Paradata.SelectionChanges != nil && *req.Paradata.SelectionChanges < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "selection_changes must not be negative")
+		}
 
 		// Ensure the option belongs to the poll
 		var option models.PollOption
 		if err := db.Where("id = ? AND poll_id = ?", optionID, pollID).First(&option).Error; err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, "Option does not belong to poll")
 		}
+		writeIn := strings.TrimSpace(req.Text)
+		if option.WriteIn && writeIn == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Text required for write-in option")
+		}
+		if !option.WriteIn && writeIn != "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Option does not accept free text")
+		}
+		if len(writeIn) > maxWriteInLength {
+			return fiber.NewError(fiber.StatusBadRequest, "Write-in text is too long")
+		}
 
 		// Get user UUID
 		userUUID, err := uuid.Parse(userIDStr)
@@ -199,14 +536,107 @@ func CastPoll(rdb *redis.Client, db *gorm.DB) fiber.Handler {
 			return fiber.ErrUnauthorized
 		}
 
+		if err := checkVoter(db, poll, userUUID); err != nil {
+			return err
+		}
+
+		// Experiment variants only accept votes from participants assigned to them
+		arm, err := armForPoll(db, pollID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check experiment")
+		}
+		if arm != nil {
+			var experiment models.Experiment
+			if err := db.Where("id = ?", arm.ExperimentID).First(&experiment).Error; err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to check experiment")
+			}
+			arms, err := experimentArms(db, experiment.ID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to check experiment")
+			}
+			assigned, err := assignArm(db, experiment, arms, userUUID, nil)
+			if err != nil {
+				if fe, ok := err.(*fiber.Error); ok {
+					return fe
+				}
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to assign arm")
+			}
+			if assigned.ID != arm.ID {
+				return fiber.NewError(fiber.StatusForbidden, "You are assigned to a different variant of this poll")
+			}
+		}
+
 		vote := models.Vote{
-			ID:       uuid.New(),
-			PollID:   pollID,
-			OptionID: optionID,
-			UserID:   userUUID,
-			VotedAt:  time.Now(),
+			ID:               uuid.New(),
+			PollID:           pollID,
+			OptionID:         optionID,
+			UserID:           userUUID,
+			VotedAt:          time.Now(),
+			SelectionChanges: req.Paradata.SelectionChanges,
+		}
+
+		// Response time runs from the participant's first fetch of the poll
+		view, err := findView(db, pollID, userUUID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check response time")
+		}
+		if view != nil {
+			latency := vote.VotedAt.Sub(view.FirstFetchedAt)
+			latencyMs := latency.Milliseconds()
+			vote.FirstFetchedAt = &view.FirstFetchedAt
+			vote.LatencyMs = &latencyMs
+			vote.ReferredBy = view.ReferredBy
+			if poll.MaxResponseSeconds > 0 && latency > time.Duration(poll.MaxResponseSeconds)*time.Second {
+				return fiber.NewError(fiber.StatusForbidden, "Response time limit exceeded")
+			}
+		} else if poll.MaxResponseSeconds > 0 {
+			return fiber.NewError(fiber.StatusConflict, "Fetch the poll before voting")
+		}
+
+		// Record which result-visibility condition the voter saw
+		if len(influenceConditions(poll)) > 0 {
+			exposure, err := findExposure(db, pollID, userUUID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to check condition")
+			}
+			if exposure == nil {
+				return fiber.NewError(fiber.StatusConflict, "Fetch the poll before voting")
+			}
+			vote.Condition = exposure.Condition
+		}
+
+		// Record where the chosen option was shown, to measure order effects
+		optionRows, err := pollOptions(db, pollID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch poll options")
+		}
+		vote.DisplayedPosition = displayedPosition(poll, userUUID, optionRows, optionID)
+		var experimentID *uuid.UUID
+		if arm != nil {
+			experimentID = &arm.ExperimentID
 		}
-		if err := db.Create(&vote).Error; err != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := recordVote(tx, poll, &vote, experimentID); err != nil {
+				return err
+			}
+			if !option.WriteIn {
+				return nil
+			}
+			return tx.Create(&models.WriteInAnswer{
+				ID:         uuid.New(),
+				PollID:     pollID,
+				OptionID:   optionID,
+				VoteID:     vote.ID,
+				UserID:     userUUID,
+				Text:       writeIn,
+				Normalized: normalizeWriteIn(writeIn),
+				CreatedAt:  vote.VotedAt,
+			}).Error
+		})
+		if err != nil {
+			if fe, ok := err.(*fiber.Error); ok {
+				return fe
+			}
 			return fiber.NewError(fiber.StatusInternalServerError, "Failed to cast vote")
 		}
 
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"gorm.io/gorm"
+)
+
+// currentUserID returns the authenticated user's ID from the request locals.
+func currentUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	userIDStr, ok := c.Locals("user_id").(string)
+	if !ok {
+		return uuid.Nil, fiber.ErrUnauthorized
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, fiber.ErrUnauthorized
+	}
+	return userID, nil
+}
+
+// findPoll loads the poll named by the poll_id route parameter.
+func findPoll(c *fiber.Ctx, db *gorm.DB) (models.Poll, error) {
+	var poll models.Poll
+	pollIDStr := c.Params("poll_id")
+	if pollIDStr == "" {
+		return poll, fiber.NewError(fiber.StatusBadRequest, "Missing poll_id")
+	}
+	pollID, err := uuid.Parse(pollIDStr)
+	if err != nil {
+		return poll, fiber.NewError(fiber.StatusBadRequest, "Invalid poll_id")
+	}
+	if err := db.Where("id = ?", pollID).First(&poll).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return poll, fiber.NewError(fiber.StatusNotFound, "Poll not found")
+		}
+		return poll, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve poll")
+	}
+	return poll, nil
+}
+
+// pollOptions returns a poll's options in display order.
+func pollOptions(db *gorm.DB, pollID uuid.UUID) ([]models.PollOption, error) {
+	var options []models.PollOption
+	err := db.Where("poll_id = ?", pollID).Order("position").Find(&options).Error
+	return options, err
+}
+
+// nextOptionPosition returns the position for an option appended to a poll.
+func nextOptionPosition(db *gorm.DB, pollID uuid.UUID) (int, error) {
+	var next int
+	err := db.Model(&models.PollOption{}).
+		Select("COALESCE(MAX(position) + 1, 0)").
+		Where("poll_id = ?", pollID).
+		Scan(&next).Error
+	return next, err
+}
+
+// findOwnedPoll loads the poll named by the poll_id route parameter and
+// checks that the authenticated user created it.
+func findOwnedPoll(c *fiber.Ctx, db *gorm.DB) (models.Poll, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	poll, err := findPoll(c, db)
+	if err != nil {
+		return poll, err
+	}
+	if poll.CreatedBy != userID.String() {
+		return poll, fiber.NewError(fiber.StatusForbidden, "Only the poll owner can do this")
+	}
+	return poll, nil
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/randomize"
+	"github.com/gopro/internal/stats"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var influenceConditionNames = map[string]bool{"visible": true, "seeded": true, "blind": true}
+
+func influenceConditions(poll models.Poll) []string {
+	if poll.InfluenceConditions == "" {
+		return nil
+	}
+	return strings.Split(poll.InfluenceConditions, ",")
+}
+
+// findExposure returns the participant's result-visibility condition for a
+// poll, or nil if they have not been shown the poll yet.
+func findExposure(db *gorm.DB, pollID, userID uuid.UUID) (*models.ResultExposure, error) {
+	var exposure models.ResultExposure
+	err := db.Where("poll_id = ? AND user_id = ?", pollID, userID).First(&exposure).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &exposure, nil
+}
+
+// exposeResults returns the participant's condition, assigning one with
+// equal probability on first view. The draw is a hash of poll and user so
+// it is stable even under concurrent requests.
+func exposeResults(db *gorm.DB, poll models.Poll, userID uuid.UUID) (models.ResultExposure, error) {
+	existing, err := findExposure(db, poll.ID, userID)
+	if err != nil {
+		return models.ResultExposure{}, err
+	}
+	if existing != nil {
+		return *existing, nil
+	}
+
+	conditions := influenceConditions(poll)
+	weights := make([]float64, len(conditions))
+	for i := range weights {
+		weights[i] = 1
+	}
+	u := randomize.Unit(poll.ID.String(), userID.String(), "visibility")
+	exposure := models.ResultExposure{
+		ID:        uuid.New(),
+		PollID:    poll.ID,
+		UserID:    userID,
+		Condition: conditions[randomize.Weighted(u, weights)],
+		ShownAt:   time.Now(),
+	}
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&exposure)
+	if res.Error != nil {
+		return exposure, res.Error
+	}
+	if res.RowsAffected == 0 {
+		err = db.Where("poll_id = ? AND user_id = ?", poll.ID, userID).First(&exposure).Error
+	}
+	return exposure, err
+}
+
+// guardTallies hides exact tallies from everyone but the owner when blind
+// participants must not see them, or when the poll has a privacy budget.
+func guardTallies(c *fiber.Ctx, poll models.Poll) error {
+	if len(influenceConditions(poll)) == 0 && poll.PrivacyBudget == 0 {
+		return nil
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	if poll.CreatedBy != userID.String() {
+		return fiber.NewError(fiber.StatusForbidden, "Results of this poll are only visible to its owner")
+	}
+	return nil
+}
+
+// shownCounts returns the per-option counts a participant in the given
+// condition may see, or nil for blind participants.
+func shownCounts(db *gorm.DB, condition string, options []models.PollOption) (map[uuid.UUID]int64, error) {
+	if condition == "blind" || len(options) == 0 {
+		return nil, nil
+	}
+	tally, err := tallyPoll(db, options[0].PollID)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uuid.UUID]int64, len(options))
+	for _, o := range tally.Options {
+		id, _ := uuid.Parse(o.OptionID)
+		counts[id] = o.Votes
+	}
+	if condition == "seeded" {
+		for _, opt := range options {
+			counts[opt.ID] += int64(opt.SeedCount)
+		}
+	}
+	return counts, nil
+}
+
+// GetInfluenceResults compares vote distributions between result-visibility
+// conditions, with a chi-square test of independence across conditions.
+func GetInfluenceResults(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		conditions := influenceConditions(poll)
+		if len(conditions) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Poll does not randomize result visibility")
+		}
+		options, err := pollOptions(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch poll options")
+		}
+
+		var rows []struct {
+			Condition string
+			OptionID  uuid.UUID
+			Votes     int64
+		}
+		err = db.Model(&models.Vote{}).
+			Select("condition, option_id, count(*) as votes").
+			Where("poll_id = ?", poll.ID).
+			Group("condition, option_id").
+			Scan(&rows).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to tally votes")
+		}
+
+		index := make(map[uuid.UUID]int, len(options))
+		for i, opt := range options {
+			index[opt.ID] = i
+		}
+		table := make([][]float64, len(conditions))
+		for i := range table {
+			table[i] = make([]float64, len(options))
+		}
+		for _, r := range rows {
+			for i, cond := range conditions {
+				if cond == r.Condition {
+					table[i][index[r.OptionID]] += float64(r.Votes)
+				}
+			}
+		}
+
+		groups := make([]fiber.Map, len(conditions))
+		for i, cond := range conditions {
+			var total float64
+			for _, v := range table[i] {
+				total += v
+			}
+			results := make([]OptionResult, len(options))
+			for j, opt := range options {
+				results[j] = OptionResult{
+					OptionID:   opt.ID.String(),
+					OptionText: opt.OptionText,
+					Position:   opt.Position,
+					Votes:      int64(table[i][j]),
+				}
+				if total > 0 {
+					results[j].Share = table[i][j] / total
+				}
+			}
+			groups[i] = fiber.Map{"condition": cond, "total_votes": int64(total), "options": results}
+		}
+
+		var chiSquare *stats.ChiSquareResult
+		if res, err := stats.ChiSquareIndependence(table); err == nil {
+			chiSquare = &res
+		}
+		return c.JSON(fiber.Map{
+			"poll_id":    poll.ID,
+			"conditions": groups,
+			"chi_square": chiSquare,
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/jobs"
+	"github.com/gopro/internal/models"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvitationView struct {
+	ID         string     `json:"invitation_id"`
+	Identifier string     `json:"identifier"`
+	Channel    string     `json:"channel"`
+	Status     string     `json:"status"` // "pending", "sent", "opened" or "voted"
+	SentAt     *time.Time `json:"sent_at"`
+	OpenedAt   *time.Time `json:"opened_at"`
+	VotedAt    *time.Time `json:"voted_at"`
+	Reminders  int        `json:"reminders"`
+}
+
+// inviteChannel picks how to reach an identifier: an email address, or a
+// phone number in E.164 form such as "+919812345678".
+func inviteChannel(identifier string) (string, bool) {
+	if at := strings.Index(identifier, "@"); at > 0 && at < len(identifier)-1 {
+		return "email", true
+	}
+	if !strings.HasPrefix(identifier, "+") || len(identifier) < 8 || len(identifier) > 16 {
+		return "", false
+	}
+	for _, r := range identifier[1:] {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return "sms", true
+}
+
+func invitationStatus(inv models.Invitation) string {
+	switch {
+	case inv.VotedAt != nil:
+		return "voted"
+	case inv.OpenedAt != nil:
+		return "opened"
+	case inv.SentAt != nil:
+		return "sent"
+	}
+	return "pending"
+}
+
+// markInvitationVoted records that the voter answered any invitation sent
+// to their identifier. Voting implies the invitation was opened.
+func markInvitationVoted(tx *gorm.DB, pollID, userID uuid.UUID, at time.Time) error {
+	return tx.Model(&models.Invitation{}).
+		Where("poll_id = ? AND voted_at IS NULL AND identifier = (SELECT identifier FROM users WHERE id = ?)", pollID, userID).
+		Updates(map[string]interface{}{
+			"voted_at":  at,
+			"opened_at": gorm.Expr("COALESCE(opened_at, ?)", at),
+		}).Error
+}
+
+// CreateInvitations invites a list of email addresses and phone numbers to
+// a poll. Each gets a personal link, sent by the worker. Identifiers that
+// were already invited are skipped.
+func CreateInvitations(db *gorm.DB, queue *asynq.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			Identifiers []string `json:"identifiers"`
+		}
+		if err := c.BodyParser(&req); err != nil || len(req.Identifiers) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Identifiers required")
+		}
+		invitations := make([]models.Invitation, 0, len(req.Identifiers))
+		for _, raw := range req.Identifiers {
+			identifier := strings.TrimSpace(raw)
+			channel, ok := inviteChannel(identifier)
+			if !ok {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid email or phone number: "+raw)
+			}
+			buf := make([]byte, 12)
+			if _, err := rand.Read(buf); err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to generate invitation link")
+			}
+			token := base64.RawURLEncoding.EncodeToString(buf)
+			invitations = append(invitations, models.Invitation{
+				ID:         uuid.New(),
+				PollID:     poll.ID,
+				Identifier: identifier,
+				Channel:    channel,
+				Token:      token,
+				Link:       c.BaseURL() + "/invitations/" + token,
+				CreatedAt:  time.Now(),
+			})
+		}
+
+		var created []models.Invitation
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, inv := range invitations {
+				res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&inv)
+				if res.Error != nil {
+					return res.Error
+				}
+				if res.RowsAffected > 0 {
+					created = append(created, inv)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create invitations")
+		}
+		for _, inv := range created {
+			_, err := queue.Enqueue(jobs.NewInvitationTask(inv.ID.String(), false), asynq.TaskID("invite:"+inv.ID.String()))
+			if err != nil && err != asynq.ErrTaskIDConflict {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to queue invitations")
+			}
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"poll_id": poll.ID,
+			"invited": len(created),
+			"skipped": len(invitations) - len(created),
+		})
+	}
+}
+
+// ListInvitations reports every invitation's status with funnel totals.
+func ListInvitations(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var invitations []models.Invitation
+		if err := db.Where("poll_id = ?", poll.ID).Order("created_at, identifier").Find(&invitations).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch invitations")
+		}
+		views := make([]InvitationView, len(invitations))
+		var sent, opened, voted int
+		for i, inv := range invitations {
+			views[i] = InvitationView{
+				ID:         inv.ID.String(),
+				Identifier: inv.Identifier,
+				Channel:    inv.Channel,
+				Status:     invitationStatus(inv),
+				SentAt:     inv.SentAt,
+				OpenedAt:   inv.OpenedAt,
+				VotedAt:    inv.VotedAt,
+				Reminders:  inv.Reminders,
+			}
+			if inv.SentAt != nil {
+				sent++
+			}
+			if inv.OpenedAt != nil {
+				opened++
+			}
+			if inv.VotedAt != nil {
+				voted++
+			}
+		}
+		summary := fiber.Map{
+			"invited": len(invitations),
+			"sent":    sent,
+			"opened":  opened,
+			"voted":   voted,
+		}
+		if sent > 0 {
+			summary["open_rate"] = float64(opened) / float64(sent)
+			summary["response_rate"] = float64(voted) / float64(sent)
+		}
+		return c.JSON(fiber.Map{
+			"poll_id":     poll.ID,
+			"summary":     summary,
+			"invitations": views,
+		})
+	}
+}
+
+// ScheduleReminders queues a reminder for every invitee who was sent an
+// invitation but has not voted. The worker checks again at send time, so
+// people who vote in the meantime are not reminded.
+func ScheduleReminders(db *gorm.DB, queue *asynq.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			SendAt *time.Time `json:"send_at"` // defaults to now
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		sendAt := time.Now()
+		if req.SendAt != nil {
+			sendAt = *req.SendAt
+		}
+		if poll.ClosedAt != nil {
+			return fiber.NewError(fiber.StatusConflict, "Poll is closed")
+		}
+
+		var pending []models.Invitation
+		err = db.Where("poll_id = ? AND sent_at IS NOT NULL AND voted_at IS NULL", poll.ID).Find(&pending).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch invitations")
+		}
+		slot := strconv.FormatInt(sendAt.Unix(), 10)
+		for _, inv := range pending {
+			_, err := queue.Enqueue(jobs.NewInvitationTask(inv.ID.String(), true),
+				asynq.ProcessAt(sendAt),
+				asynq.TaskID("remind:"+inv.ID.String()+":"+slot))
+			if err != nil && err != asynq.ErrTaskIDConflict {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to schedule reminders")
+			}
+		}
+		return c.JSON(fiber.Map{
+			"poll_id":   poll.ID,
+			"scheduled": len(pending),
+			"send_at":   sendAt,
+		})
+	}
+}
+
+// OpenInvitation records that an invitation link was followed and sends the
+// invitee on to the poll. It is public so opens count before sign-in.
+func OpenInvitation(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var inv models.Invitation
+		if err := db.Where("token = ?", c.Params("token")).First(&inv).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fiber.NewError(fiber.StatusNotFound, "Invitation not found")
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve invitation")
+		}
+		err := db.Model(&models.Invitation{}).
+			Where("id = ? AND opened_at IS NULL", inv.ID).
+			Update("opened_at", time.Now()).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to record invitation open")
+		}
+		var poll models.Poll
+		if err := db.Where("id = ?", inv.PollID).First(&poll).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve poll")
+		}
+		return c.Redirect(poll.ShareableLink, fiber.StatusFound)
+	}
+}
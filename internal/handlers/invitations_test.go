@@ -0,0 +1,31 @@
+package handlers
+
+import "testing"
+
+func TestInviteChannel(t *testing.T) {
+	channels := map[string]string{
+		"ada@example.com": "email",
+		"a@b":             "email",
+		"+919812345678":   "sms",
+		"+14155550123":    "sms",
+	}
+	for identifier, want := range channels {
+		if got, ok := inviteChannel(identifier); !ok || got != want {
+			t.Errorf("inviteChannel(%q) = %q, %v; want %q", identifier, got, ok, want)
+		}
+	}
+
+	for _, identifier := range []string{
+		"@example.com",
+		"ada@",
+		"919812345678",      // E.164 needs the leading +
+		"+1234",             // too short
+		"+1234567890123456", // too long
+		"+1415555O123",      // letter O, not zero
+		"",
+	} {
+		if got, ok := inviteChannel(identifier); ok {
+			t.Errorf("inviteChannel(%q) = %q, want rejected", identifier, got)
+		}
+	}
+}
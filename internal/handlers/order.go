@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"math/rand/v2"
+
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/randomize"
+	"github.com/gopro/internal/stats"
+	"gorm.io/gorm"
+)
+
+// OrderEffects reports how often the chosen option was shown at each
+// position. With shuffled options every position is equally likely to hold
+// the chosen option, so deviations measure primacy and recency bias.
+type OrderEffects struct {
+	Positions []PositionShare        `json:"positions"`
+	Expected  float64                `json:"expected_share"`
+	Primacy   float64                `json:"primacy"` // first-position share minus expected
+	Recency   float64                `json:"recency"` // last-position share minus expected
+	ChiSquare *stats.ChiSquareResult `json:"chi_square"`
+}
+
+type PositionShare struct {
+	Position int     `json:"displayed_position"`
+	Votes    int64   `json:"votes"`
+	Share    float64 `json:"share"`
+}
+
+// displayOrder returns the options in the order the participant sees them.
+// Shuffled polls use a permutation seeded by poll and user, so the order is
+// the same on every fetch and can be recomputed when the vote arrives.
+// Write-in options stay at the end, where "Other" is expected.
+func displayOrder(poll models.Poll, userID uuid.UUID, options []models.PollOption) []models.PollOption {
+	if !poll.ShuffleOptions {
+		return options
+	}
+	ordered := make([]models.PollOption, 0, len(options))
+	var writeIns []models.PollOption
+	for _, opt := range options {
+		if opt.WriteIn {
+			writeIns = append(writeIns, opt)
+		} else {
+			ordered = append(ordered, opt)
+		}
+	}
+	r := rand.New(rand.NewPCG(randomize.Seed(poll.ID.String(), userID.String(), "order"), 0))
+	r.Shuffle(len(ordered), func(i, j int) { ordered[i], ordered[j] = ordered[j], ordered[i] })
+	return append(ordered, writeIns...)
+}
+
+// displayedPosition returns where an option appeared for the participant.
+func displayedPosition(poll models.Poll, userID uuid.UUID, options []models.PollOption, optionID uuid.UUID) *int {
+	for i, opt := range displayOrder(poll, userID, options) {
+		if opt.ID == optionID {
+			return &i
+		}
+	}
+	return nil
+}
+
+// orderEffects tallies votes by displayed position. Write-in options are
+// pinned last rather than shuffled, so their votes are left out.
+func orderEffects(db *gorm.DB, pollID uuid.UUID) (*OrderEffects, error) {
+	var shuffled int64
+	err := db.Model(&models.PollOption{}).
+		Where("poll_id = ? AND write_in = ?", pollID, false).
+		Count(&shuffled).Error
+	if err != nil {
+		return nil, err
+	}
+	if shuffled < 2 {
+		return nil, nil
+	}
+
+	var rows []struct {
+		Position int
+		Votes    int64
+	}
+	err = db.Table("votes").
+		Select("votes.displayed_position as position, count(*) as votes").
+		Joins("JOIN poll_options ON poll_options.id = votes.option_id").
+		Where("votes.poll_id = ? AND votes.displayed_position IS NOT NULL AND poll_options.write_in = ?", pollID, false).
+		Group("votes.displayed_position").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	k := int(shuffled)
+	observed := make([]float64, k)
+	expected := make([]float64, k)
+	var total float64
+	for _, r := range rows {
+		// Positions beyond the current option count come from options
+		// that were removed after voting started.
+		if r.Position < k {
+			observed[r.Position] += float64(r.Votes)
+			total += float64(r.Votes)
+		}
+	}
+	effects := &OrderEffects{
+		Positions: make([]PositionShare, k),
+		Expected:  1 / float64(k),
+	}
+	for i := range observed {
+		expected[i] = 1
+		effects.Positions[i] = PositionShare{Position: i, Votes: int64(observed[i])}
+		if total > 0 {
+			effects.Positions[i].Share = observed[i] / total
+		}
+	}
+	if total > 0 {
+		effects.Primacy = effects.Positions[0].Share - effects.Expected
+		effects.Recency = effects.Positions[k-1].Share - effects.Expected
+	}
+	if res, err := stats.ChiSquareGoodnessOfFit(observed, expected); err == nil {
+		effects.ChiSquare = &res
+	}
+	return effects, nil
+}
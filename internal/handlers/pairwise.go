@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/jobs"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/stats"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+type pairOption struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type RankedOption struct {
+	OptionID    string   `json:"option_id"`
+	OptionText  string   `json:"option_text"`
+	Elo         float64  `json:"elo"`
+	Score       *float64 `json:"bt_score,omitempty"`
+	Lower       *float64 `json:"bt_lower,omitempty"`
+	Upper       *float64 `json:"bt_upper,omitempty"`
+	Comparisons int      `json:"comparisons"`
+}
+
+func eloKey(pollID uuid.UUID) string {
+	return fmt.Sprintf("poll:%s:elo", pollID)
+}
+
+func servedPairKey(pollID, userID uuid.UUID) string {
+	return fmt.Sprintf("poll:%s:pair:%s", pollID, userID)
+}
+
+func findPairwisePoll(c *fiber.Ctx, db *gorm.DB) (models.Poll, error) {
+	poll, err := findPoll(c, db)
+	if err != nil {
+		return poll, err
+	}
+	if poll.Mode != "pairwise" {
+		return poll, fiber.NewError(fiber.StatusBadRequest, "Poll is not a pairwise poll")
+	}
+	return poll, nil
+}
+
+// GetPair serves a random pair of options from a pairwise poll and
+// remembers it so the following comparison can be checked against it.
+func GetPair(rdb *redis.Client, db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		poll, err := findPairwisePoll(c, db)
+		if err != nil {
+			return err
+		}
+		if poll.Phase == "submission" {
+			return fiber.NewError(fiber.StatusConflict, "Poll is still collecting options")
+		}
+
+		var options []models.PollOption
+		if err := db.Where("poll_id = ?", poll.ID).Find(&options).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch poll options")
+		}
+		if len(options) < 2 {
+			return fiber.NewError(fiber.StatusConflict, "Poll has fewer than 2 options")
+		}
+
+		perm := rand.Perm(len(options))
+		left, right := options[perm[0]], options[perm[1]]
+
+		served := left.ID.String() + ":" + right.ID.String()
+		if err := rdb.Set(c.Context(), servedPairKey(poll.ID, userID), served, 30*time.Minute).Err(); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to save pair")
+		}
+
+		return c.JSON(fiber.Map{
+			"poll_id": poll.ID,
+			"left":    pairOption{ID: left.ID.String(), Text: left.OptionText},
+			"right":   pairOption{ID: right.ID.String(), Text: right.OptionText},
+		})
+	}
+}
+
+// ComparePair records the voter's choice for the pair last served to them
+// and updates the live Elo ratings.
+func ComparePair(rdb *redis.Client, db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		poll, err := findPairwisePoll(c, db)
+		if err != nil {
+			return err
+		}
+
+		var req struct {
+			WinnerID string `json:"winner_id"`
+			LoserID  string `json:"loser_id"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		winnerID, err := uuid.Parse(req.WinnerID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid winner_id")
+		}
+		loserID, err := uuid.Parse(req.LoserID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid loser_id")
+		}
+
+		ctx := c.Context()
+		pairKey := servedPairKey(poll.ID, userID)
+		served, err := rdb.GetDel(ctx, pairKey).Result()
+		if err == redis.Nil {
+			return fiber.NewError(fiber.StatusConflict, "No pair was served; fetch /polls/:poll_id/pair first")
+		}
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to read served pair")
+		}
+		ids := strings.Split(served, ":")
+		if len(ids) != 2 ||
+			!(ids[0] == winnerID.String() && ids[1] == loserID.String()) &&
+				!(ids[1] == winnerID.String() && ids[0] == loserID.String()) {
+			return fiber.NewError(fiber.StatusBadRequest, "Choice does not match the served pair")
+		}
+
+		comparison := models.PairwiseComparison{
+			ID:         uuid.New(),
+			PollID:     poll.ID,
+			WinnerID:   winnerID,
+			LoserID:    loserID,
+			UserID:     userID,
+			ComparedAt: time.Now(),
+		}
+		if err := db.Create(&comparison).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to record comparison")
+		}
+
+		winnerElo, loserElo, err := updateElo(ctx, rdb, poll.ID, winnerID, loserID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to update ratings")
+		}
+
+		return c.JSON(fiber.Map{
+			"message":    "Comparison recorded",
+			"winner_elo": winnerElo,
+			"loser_elo":  loserElo,
+		})
+	}
+}
+
+// updateElo applies one Elo match to the poll's rating set, retrying if
+// another request changed the set concurrently.
+func updateElo(ctx context.Context, rdb *redis.Client, pollID, winnerID, loserID uuid.UUID) (float64, float64, error) {
+	key := eloKey(pollID)
+	var newWinner, newLoser float64
+	update := func(tx *redis.Tx) error {
+		winner, err := tx.ZScore(ctx, key, winnerID.String()).Result()
+		if err == redis.Nil {
+			winner = stats.EloInitial
+		} else if err != nil {
+			return err
+		}
+		loser, err := tx.ZScore(ctx, key, loserID.String()).Result()
+		if err == redis.Nil {
+			loser = stats.EloInitial
+		} else if err != nil {
+			return err
+		}
+		newWinner, newLoser = stats.EloUpdate(winner, loser)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZAdd(ctx, key,
+				redis.Z{Score: newWinner, Member: winnerID.String()},
+				redis.Z{Score: newLoser, Member: loserID.String()},
+			)
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		err := rdb.Watch(ctx, update, key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		return newWinner, newLoser, err
+	}
+	return 0, 0, redis.TxFailedErr
+}
+
+// GetPairwiseRanking returns the live Elo ranking of a pairwise poll along
+// with the latest Bradley-Terry fit computed by the worker.
+func GetPairwiseRanking(rdb *redis.Client, db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findPairwisePoll(c, db)
+		if err != nil {
+			return err
+		}
+
+		options, err := pollOptions(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch poll options")
+		}
+
+		elo, err := rdb.ZRangeWithScores(c.Context(), eloKey(poll.ID), 0, -1).Result()
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch ratings")
+		}
+		eloByOption := make(map[string]float64, len(elo))
+		for _, z := range elo {
+			eloByOption[z.Member.(string)] = z.Score
+		}
+
+		var scores []models.PairwiseScore
+		if err := db.Where("poll_id = ?", poll.ID).Find(&scores).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch fitted scores")
+		}
+		scoreByOption := make(map[uuid.UUID]models.PairwiseScore, len(scores))
+		var fittedAt *time.Time
+		for _, s := range scores {
+			scoreByOption[s.OptionID] = s
+			fittedAt = &s.ComputedAt
+		}
+
+		ranking := make([]RankedOption, 0, len(options))
+		for _, opt := range options {
+			r := RankedOption{
+				OptionID:   opt.ID.String(),
+				OptionText: opt.OptionText,
+				Elo:        stats.EloInitial,
+			}
+			if v, ok := eloByOption[opt.ID.String()]; ok {
+				r.Elo = v
+			}
+			if s, ok := scoreByOption[opt.ID]; ok {
+				r.Score, r.Lower, r.Upper = &s.Score, &s.Lower, &s.Upper
+				r.Comparisons = s.Comparisons
+			}
+			ranking = append(ranking, r)
+		}
+		sort.SliceStable(ranking, func(i, j int) bool {
+			return ranking[i].Elo > ranking[j].Elo
+		})
+
+		return c.JSON(fiber.Map{
+			"poll_id":   poll.ID,
+			"ranking":   ranking,
+			"fitted_at": fittedAt,
+		})
+	}
+}
+
+// RefreshPairwiseRanking queues a Bradley-Terry fit for the poll.
+func RefreshPairwiseRanking(db *gorm.DB, queue *asynq.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		if poll.Mode != "pairwise" {
+			return fiber.NewError(fiber.StatusBadRequest, "Poll is not a pairwise poll")
+		}
+
+		_, err = queue.Enqueue(jobs.NewPairwiseFitTask(poll.ID.String()), asynq.Unique(time.Minute))
+		if err != nil && err != asynq.ErrDuplicateTask {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to queue ranking fit")
+		}
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "Ranking fit queued"})
+	}
+}
@@ -0,0 +1,540 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/jobs"
+	"github.com/gopro/internal/models"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WaveView struct {
+	WaveNumber int        `json:"wave_number"`
+	PollID     string     `json:"poll_id"`
+	OpensAt    time.Time  `json:"opens_at"`
+	InvitedAt  *time.Time `json:"invited_at"`
+}
+
+func findPanel(c *fiber.Ctx, db *gorm.DB) (models.Panel, error) {
+	var panel models.Panel
+	panelID, err := uuid.Parse(c.Params("panel_id"))
+	if err != nil {
+		return panel, fiber.NewError(fiber.StatusBadRequest, "Invalid panel_id")
+	}
+	if err := db.Where("id = ?", panelID).First(&panel).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return panel, fiber.NewError(fiber.StatusNotFound, "Panel not found")
+		}
+		return panel, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve panel")
+	}
+	return panel, nil
+}
+
+func findOwnedPanel(c *fiber.Ctx, db *gorm.DB) (models.Panel, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return models.Panel{}, err
+	}
+	panel, err := findPanel(c, db)
+	if err != nil {
+		return panel, err
+	}
+	if panel.CreatedBy != userID.String() {
+		return panel, fiber.NewError(fiber.StatusForbidden, "Only the panel owner can do this")
+	}
+	return panel, nil
+}
+
+func panelWaves(db *gorm.DB, panelID uuid.UUID) ([]models.PanelWave, error) {
+	var waves []models.PanelWave
+	err := db.Where("panel_id = ?", panelID).Order("wave_number").Find(&waves).Error
+	return waves, err
+}
+
+// waveForPoll returns the panel wave a poll belongs to, or nil.
+func waveForPoll(db *gorm.DB, pollID uuid.UUID) (*models.PanelWave, error) {
+	var wave models.PanelWave
+	err := db.Where("poll_id = ?", pollID).First(&wave).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wave, nil
+}
+
+// checkWaveVoter returns a fiber error unless the user may vote on the
+// wave now: the wave has opened and the user is a panel member.
+func checkWaveVoter(db *gorm.DB, wave models.PanelWave, userID uuid.UUID) error {
+	if time.Now().Before(wave.OpensAt) {
+		return fiber.NewError(fiber.StatusConflict, "This wave is not open yet")
+	}
+	var count int64
+	err := db.Model(&models.PanelMember{}).Where("panel_id = ? AND user_id = ?", wave.PanelID, userID).Count(&count).Error
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to check panel membership")
+	}
+	if count == 0 {
+		return fiber.NewError(fiber.StatusForbidden, "Only panel members can answer this poll")
+	}
+	return nil
+}
+
+// addMembers links users to the panel by email or phone, creating users
+// who have not signed in yet.
+func addMembers(tx *gorm.DB, panelID uuid.UUID, identifiers []string) (int, error) {
+	var added int
+	for _, identifier := range identifiers {
+		if identifier == "" {
+			continue
+		}
+		user := models.User{ID: uuid.New(), Identifier: identifier}
+		if err := tx.Where("identifier = ?", identifier).FirstOrCreate(&user).Error; err != nil {
+			return added, err
+		}
+		member := models.PanelMember{ID: uuid.New(), PanelID: panelID, UserID: user.ID, JoinedAt: time.Now()}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&member)
+		if res.Error != nil {
+			return added, res.Error
+		}
+		added += int(res.RowsAffected)
+	}
+	return added, nil
+}
+
+// clonePoll copies a poll for a new wave: its options and settings, the
+// privacy budget and result-visibility conditions, the current consent text
+// and the screeners, so every wave runs with the protections of the first.
+// The privacy budget starts unspent for each wave.
+func clonePoll(tx *gorm.DB, source models.Poll, title, baseURL string) (models.Poll, error) {
+	options, err := pollOptions(tx, source.ID)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	poll := models.Poll{
+		ID:                  uuid.New(),
+		WebsiteID:           source.WebsiteID,
+		Title:               title,
+		Description:         source.Description,
+		CreatedBy:           source.CreatedBy,
+		CreatedAt:           time.Now(),
+		Mode:                source.Mode,
+		Phase:               "voting",
+		InfluenceConditions: source.InfluenceConditions,
+		ShuffleOptions:      source.ShuffleOptions,
+		MaxResponseSeconds:  source.MaxResponseSeconds,
+		PrivacyBudget:       source.PrivacyBudget,
+		PrivacyEpsilon:      source.PrivacyEpsilon,
+		PrivacyDelta:        source.PrivacyDelta,
+		PrivacyMechanism:    source.PrivacyMechanism,
+	}
+	poll.ShareableLink = baseURL + "/poll/" + poll.ID.String()
+	if err := tx.Create(&poll).Error; err != nil {
+		return poll, err
+	}
+	for _, opt := range options {
+		clone := models.PollOption{
+			ID:         uuid.New(),
+			PollID:     poll.ID,
+			OptionText: opt.OptionText,
+			WriteIn:    opt.WriteIn,
+			Position:   opt.Position,
+			SeedCount:  opt.SeedCount,
+		}
+		if err := tx.Create(&clone).Error; err != nil {
+			return poll, err
+		}
+		if source.CorrectOptionID != nil && *source.CorrectOptionID == opt.ID {
+			if err := tx.Model(&poll).Update("correct_option_id", clone.ID).Error; err != nil {
+				return poll, err
+			}
+		}
+	}
+
+	consentText, err := currentConsentText(tx, source.ID)
+	if err != nil {
+		return poll, err
+	}
+	if consentText != nil {
+		text := models.ConsentText{
+			ID:        uuid.New(),
+			PollID:    poll.ID,
+			Version:   1,
+			Text:      consentText.Text,
+			CreatedAt: time.Now(),
+		}
+		if err := tx.Create(&text).Error; err != nil {
+			return poll, err
+		}
+	}
+
+	screeners, err := pollScreeners(tx, source.ID)
+	if err != nil {
+		return poll, err
+	}
+	for _, s := range screeners {
+		s.ID = uuid.New()
+		s.PollID = poll.ID
+		if err := tx.Create(&s).Error; err != nil {
+			return poll, err
+		}
+	}
+	return poll, nil
+}
+
+func CreatePanel(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			Title       string   `json:"title"`
+			PollID      string   `json:"poll_id"`     // template for every wave
+			Identifiers []string `json:"identifiers"` // members' emails or phone numbers
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		if req.Title == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Title required")
+		}
+		pollID, err := uuid.Parse(req.PollID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid poll_id")
+		}
+		var source models.Poll
+		if err := db.Where("id = ?", pollID).First(&source).Error; err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Poll not found")
+		}
+		if source.CreatedBy != userID.String() {
+			return fiber.NewError(fiber.StatusForbidden, "Panels must use a poll you created")
+		}
+		if source.Mode != "standard" || source.SurveyID != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Panels must use a standard poll")
+		}
+
+		panel := models.Panel{
+			ID:           uuid.New(),
+			Title:        req.Title,
+			CreatedBy:    userID.String(),
+			CreatedAt:    time.Now(),
+			SourcePollID: source.ID,
+		}
+		var added int
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&panel).Error; err != nil {
+				return err
+			}
+			added, err = addMembers(tx, panel.ID, req.Identifiers)
+			return err
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create panel")
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"panel_id":       panel.ID,
+			"title":          panel.Title,
+			"source_poll_id": panel.SourcePollID,
+			"members":        added,
+		})
+	}
+}
+
+func AddPanelMembers(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		panel, err := findOwnedPanel(c, db)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			Identifiers []string `json:"identifiers"`
+		}
+		if err := c.BodyParser(&req); err != nil || len(req.Identifiers) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Identifiers required")
+		}
+		var added int
+		err = db.Transaction(func(tx *gorm.DB) error {
+			added, err = addMembers(tx, panel.ID, req.Identifiers)
+			return err
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to add members")
+		}
+		return c.JSON(fiber.Map{"panel_id": panel.ID, "added": added})
+	}
+}
+
+// CreateWave copies the panel's poll for a new wave and schedules the
+// invitations for when it opens.
+func CreateWave(db *gorm.DB, queue *asynq.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		panel, err := findOwnedPanel(c, db)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			OpensAt *time.Time `json:"opens_at"` // defaults to now
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		opensAt := time.Now()
+		if req.OpensAt != nil {
+			opensAt = *req.OpensAt
+		}
+		var source models.Poll
+		if err := db.Where("id = ?", panel.SourcePollID).First(&source).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch source poll")
+		}
+
+		var wave models.PanelWave
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var last struct{ N int }
+			err := tx.Model(&models.PanelWave{}).
+				Select("COALESCE(MAX(wave_number), 0) as n").
+				Where("panel_id = ?", panel.ID).
+				Scan(&last).Error
+			if err != nil {
+				return err
+			}
+			number := last.N + 1
+			poll, err := clonePoll(tx, source, source.Title+" (wave "+strconv.Itoa(number)+")", c.BaseURL())
+			if err != nil {
+				return err
+			}
+			wave = models.PanelWave{
+				ID:         uuid.New(),
+				PanelID:    panel.ID,
+				PollID:     poll.ID,
+				WaveNumber: number,
+				OpensAt:    opensAt,
+			}
+			return tx.Create(&wave).Error
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create wave")
+		}
+
+		_, err = queue.Enqueue(jobs.NewWaveInvitationTask(wave.ID.String()), asynq.ProcessAt(opensAt))
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to schedule invitations")
+		}
+		return c.Status(fiber.StatusCreated).JSON(WaveView{
+			WaveNumber: wave.WaveNumber,
+			PollID:     wave.PollID.String(),
+			OpensAt:    wave.OpensAt,
+		})
+	}
+}
+
+func GetPanel(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		panel, err := findOwnedPanel(c, db)
+		if err != nil {
+			return err
+		}
+		var members int64
+		if err := db.Model(&models.PanelMember{}).Where("panel_id = ?", panel.ID).Count(&members).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to count members")
+		}
+		waves, err := panelWaves(db, panel.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch waves")
+		}
+		views := make([]WaveView, len(waves))
+		for i, w := range waves {
+			views[i] = WaveView{WaveNumber: w.WaveNumber, PollID: w.PollID.String(), OpensAt: w.OpensAt, InvitedAt: w.InvitedAt}
+		}
+		return c.JSON(fiber.Map{
+			"panel_id":       panel.ID,
+			"title":          panel.Title,
+			"source_poll_id": panel.SourcePollID,
+			"members":        members,
+			"waves":          views,
+		})
+	}
+}
+
+// memberAnswers maps each panel member who voted on the poll to the
+// position of their latest answer.
+func memberAnswers(db *gorm.DB, panelID, pollID uuid.UUID) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		UserID   uuid.UUID
+		Position int
+	}
+	err := db.Table("votes").
+		Select("votes.user_id, poll_options.position").
+		Joins("JOIN poll_options ON poll_options.id = votes.option_id").
+		Joins("JOIN panel_members ON panel_members.user_id = votes.user_id AND panel_members.panel_id = ?", panelID).
+		Where("votes.poll_id = ?", pollID).
+		Order("votes.voted_at").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	answers := make(map[uuid.UUID]int, len(rows))
+	for _, r := range rows {
+		answers[r.UserID] = r.Position
+	}
+	return answers, nil
+}
+
+// GetPanelTransitions cross-tabulates how members answered in one wave
+// against another (by default the last two waves).
+func GetPanelTransitions(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		panel, err := findOwnedPanel(c, db)
+		if err != nil {
+			return err
+		}
+		waves, err := panelWaves(db, panel.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch waves")
+		}
+		if len(waves) < 2 {
+			return fiber.NewError(fiber.StatusConflict, "Panel needs at least 2 waves")
+		}
+		from := c.QueryInt("from", len(waves)-1)
+		to := c.QueryInt("to", len(waves))
+		if from < 1 || to < 1 || from > len(waves) || to > len(waves) || from == to {
+			return fiber.NewError(fiber.StatusBadRequest, "from and to must be different wave numbers")
+		}
+		fromWave, toWave := waves[from-1], waves[to-1]
+
+		options, err := pollOptions(db, fromWave.PollID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch poll options")
+		}
+		before, err := memberAnswers(db, panel.ID, fromWave.PollID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch answers")
+		}
+		after, err := memberAnswers(db, panel.ID, toWave.PollID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch answers")
+		}
+
+		labels := make([]string, len(options))
+		index := make(map[int]int, len(options))
+		for i, opt := range options {
+			labels[i] = opt.OptionText
+			index[opt.Position] = i
+		}
+		counts := make([][]int64, len(options))
+		for i := range counts {
+			counts[i] = make([]int64, len(options))
+		}
+		var linked, changed int64
+		for userID, a := range before {
+			b, ok := after[userID]
+			i, okA := index[a]
+			j, okB := index[b]
+			if !ok || !okA || !okB {
+				continue
+			}
+			counts[i][j]++
+			linked++
+			if i != j {
+				changed++
+			}
+		}
+		// Row shares estimate P(answer in "to" | answer in "from").
+		shares := make([][]float64, len(options))
+		for i, row := range counts {
+			shares[i] = make([]float64, len(options))
+			var total int64
+			for _, n := range row {
+				total += n
+			}
+			for j, n := range row {
+				if total > 0 {
+					shares[i][j] = float64(n) / float64(total)
+				}
+			}
+		}
+		var changeRate float64
+		if linked > 0 {
+			changeRate = float64(changed) / float64(linked)
+		}
+		return c.JSON(fiber.Map{
+			"panel_id":    panel.ID,
+			"from_wave":   from,
+			"to_wave":     to,
+			"options":     labels,
+			"counts":      counts,
+			"row_shares":  shares,
+			"linked":      linked,
+			"changed":     changed,
+			"change_rate": changeRate,
+		})
+	}
+}
+
+// GetPanelAttrition reports, per wave, how many members responded and how
+// many of the first wave's and previous wave's respondents were retained.
+func GetPanelAttrition(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		panel, err := findOwnedPanel(c, db)
+		if err != nil {
+			return err
+		}
+		var members int64
+		if err := db.Model(&models.PanelMember{}).Where("panel_id = ?", panel.ID).Count(&members).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to count members")
+		}
+		waves, err := panelWaves(db, panel.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch waves")
+		}
+
+		stats := make([]fiber.Map, len(waves))
+		var first, previous map[uuid.UUID]int
+		for i, w := range waves {
+			answers, err := memberAnswers(db, panel.ID, w.PollID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch answers")
+			}
+			row := fiber.Map{
+				"wave_number": w.WaveNumber,
+				"responded":   len(answers),
+			}
+			if members > 0 {
+				row["response_rate"] = float64(len(answers)) / float64(members)
+			}
+			if i > 0 {
+				row["retained_from_first"] = retention(first, answers)
+				row["retained_from_previous"] = retention(previous, answers)
+			} else {
+				first = answers
+			}
+			previous = answers
+			stats[i] = row
+		}
+		return c.JSON(fiber.Map{
+			"panel_id": panel.ID,
+			"members":  members,
+			"waves":    stats,
+		})
+	}
+}
+
+// retention is the share of earlier respondents who answered again.
+func retention(earlier, later map[uuid.UUID]int) float64 {
+	if len(earlier) == 0 {
+		return 0
+	}
+	var kept int
+	for userID := range earlier {
+		if _, ok := later[userID]; ok {
+			kept++
+		}
+	}
+	return float64(kept) / float64(len(earlier))
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/stats"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LatencyGroup describes response times (in milliseconds) and client-side
+// selection changes for one option or experiment arm.
+type LatencyGroup struct {
+	Label            string        `json:"label"`
+	LatencyMs        stats.Summary `json:"latency_ms"`
+	SelectionChanges stats.Summary `json:"selection_changes"`
+}
+
+// recordView stores the participant's first fetch of a poll; later fetches
+// keep the original time and referrer.
+func recordView(db *gorm.DB, pollID, userID uuid.UUID, referredBy *uuid.UUID) (models.PollView, error) {
+	view := models.PollView{
+		ID:             uuid.New(),
+		PollID:         pollID,
+		UserID:         userID,
+		FirstFetchedAt: time.Now(),
+		ReferredBy:     referredBy,
+	}
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&view)
+	if res.Error != nil {
+		return view, res.Error
+	}
+	var err error
+	if res.RowsAffected == 0 {
+		err = db.Where("poll_id = ? AND user_id = ?", pollID, userID).First(&view).Error
+	}
+	return view, err
+}
+
+func findView(db *gorm.DB, pollID, userID uuid.UUID) (*models.PollView, error) {
+	var view models.PollView
+	err := db.Where("poll_id = ? AND user_id = ?", pollID, userID).First(&view).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// latencyGroups summarizes paradata of a poll's votes per option, in option
+// order. Votes cast before paradata was collected are skipped.
+func latencyGroups(db *gorm.DB, pollID uuid.UUID) ([]LatencyGroup, error) {
+	options, err := pollOptions(db, pollID)
+	if err != nil {
+		return nil, err
+	}
+	var votes []models.Vote
+	if err := db.Where("poll_id = ? AND latency_ms IS NOT NULL", pollID).Find(&votes).Error; err != nil {
+		return nil, err
+	}
+
+	latencies := make(map[uuid.UUID][]float64, len(options))
+	changes := make(map[uuid.UUID][]float64, len(options))
+	for _, v := range votes {
+		latencies[v.OptionID] = append(latencies[v.OptionID], float64(*v.LatencyMs))
+		if v.SelectionChanges != nil {
+			changes[v.OptionID] = append(changes[v.OptionID], float64(*v.SelectionChanges))
+		}
+	}
+	groups := make([]LatencyGroup, len(options))
+	for i, opt := range options {
+		groups[i] = LatencyGroup{
+			Label:            opt.OptionText,
+			LatencyMs:        stats.Describe(latencies[opt.ID]),
+			SelectionChanges: stats.Describe(changes[opt.ID]),
+		}
+	}
+	return groups, nil
+}
+
+// pollLatency summarizes paradata across all of a poll's votes.
+func pollLatency(db *gorm.DB, pollID uuid.UUID) (LatencyGroup, error) {
+	var votes []models.Vote
+	if err := db.Where("poll_id = ? AND latency_ms IS NOT NULL", pollID).Find(&votes).Error; err != nil {
+		return LatencyGroup{}, err
+	}
+	latencies := make([]float64, 0, len(votes))
+	var changes []float64
+	for _, v := range votes {
+		latencies = append(latencies, float64(*v.LatencyMs))
+		if v.SelectionChanges != nil {
+			changes = append(changes, float64(*v.SelectionChanges))
+		}
+	}
+	return LatencyGroup{
+		LatencyMs:        stats.Describe(latencies),
+		SelectionChanges: stats.Describe(changes),
+	}, nil
+}
+
+// GetPollLatency reports response-time distributions per option and overall.
+func GetPollLatency(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findPoll(c, db)
+		if err != nil {
+			return err
+		}
+		if err := guardTallies(c, poll); err != nil {
+			return err
+		}
+		groups, err := latencyGroups(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch response times")
+		}
+		overall, err := pollLatency(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch response times")
+		}
+		overall.Label = "all"
+
+		return c.JSON(fiber.Map{
+			"poll_id":              poll.ID,
+			"max_response_seconds": poll.MaxResponseSeconds,
+			"overall":              overall,
+			"options":              groups,
+		})
+	}
+}
+
+// GetExperimentLatency compares response-time distributions between arms.
+func GetExperimentLatency(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		experiment, err := findExperiment(c, db)
+		if err != nil {
+			return err
+		}
+		arms, err := experimentArms(db, experiment.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+		}
+
+		groups := make([]LatencyGroup, len(arms))
+		for i, arm := range arms {
+			groups[i], err = pollLatency(db, arm.PollID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch response times")
+			}
+			groups[i].Label = arm.Name
+		}
+
+		return c.JSON(fiber.Map{
+			"experiment_id": experiment.ID,
+			"arms":          groups,
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/stats"
+	"gorm.io/gorm"
+)
+
+// powerDesign describes the comparison an experiment is sized for: every
+// treatment arm against the control arm, detecting an absolute lift of MDE
+// over the baseline share.
+type powerDesign struct {
+	Baseline float64 `json:"baseline"`
+	MDE      float64 `json:"mde"`
+	Alpha    float64 `json:"alpha"`
+	Power    float64 `json:"power"`
+	Arms     int     `json:"arms"`
+}
+
+// AdjustedAlpha applies a Bonferroni correction across the arms-1
+// comparisons against control.
+func (d powerDesign) AdjustedAlpha() float64 {
+	if d.Arms <= 2 {
+		return d.Alpha
+	}
+	return d.Alpha / float64(d.Arms-1)
+}
+
+func (d *powerDesign) validate() error {
+	if d.Alpha == 0 {
+		d.Alpha = 0.05
+	}
+	if d.Power == 0 {
+		d.Power = 0.8
+	}
+	if d.Arms == 0 {
+		d.Arms = 2
+	}
+	if d.Arms < 2 {
+		return fiber.NewError(fiber.StatusBadRequest, "At least 2 arms required")
+	}
+	if d.Baseline <= 0 || d.Baseline >= 1 || d.MDE == 0 || d.Baseline+d.MDE <= 0 || d.Baseline+d.MDE >= 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "baseline and baseline+mde must be between 0 and 1, and mde non-zero")
+	}
+	if d.Alpha <= 0 || d.Alpha >= 1 || d.Power <= 0 || d.Power >= 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "alpha and power must be between 0 and 1")
+	}
+	return nil
+}
+
+func (d powerDesign) perArm() (int, error) {
+	return stats.SampleSizeTwoProportions(d.Baseline, d.Baseline+d.MDE, d.AdjustedAlpha(), d.Power)
+}
+
+// armVoteCounts returns the number of votes cast in each arm's poll.
+func armVoteCounts(db *gorm.DB, arms []models.ExperimentArm) ([]int, error) {
+	counts := make([]int, len(arms))
+	for i, arm := range arms {
+		var n int64
+		if err := db.Model(&models.Vote{}).Where("poll_id = ?", arm.PollID).Count(&n).Error; err != nil {
+			return nil, err
+		}
+		counts[i] = int(n)
+	}
+	return counts, nil
+}
+
+// ComputePower returns the sample size per arm for a design and, when an
+// experiment is given, the power its current sample already achieves.
+func ComputePower(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req struct {
+			powerDesign
+			ExperimentID string `json:"experiment_id"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+
+		var arms []models.ExperimentArm
+		if req.ExperimentID != "" {
+			experimentID, err := uuid.Parse(req.ExperimentID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid experiment_id")
+			}
+			if err := db.Where("id = ?", experimentID).First(&models.Experiment{}).Error; err != nil {
+				return fiber.NewError(fiber.StatusNotFound, "Experiment not found")
+			}
+			if arms, err = experimentArms(db, experimentID); err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+			}
+			req.Arms = len(arms)
+		}
+		if err := req.validate(); err != nil {
+			return err
+		}
+		n, err := req.perArm()
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid design parameters")
+		}
+
+		resp := fiber.Map{
+			"baseline":       req.Baseline,
+			"mde":            req.MDE,
+			"alpha":          req.Alpha,
+			"adjusted_alpha": req.AdjustedAlpha(),
+			"power":          req.Power,
+			"arms":           req.Arms,
+			"per_arm":        n,
+			"total":          n * req.Arms,
+		}
+		if arms == nil {
+			return c.JSON(resp)
+		}
+
+		counts, err := armVoteCounts(db, arms)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to count votes")
+		}
+		achieved := make([]fiber.Map, 0, len(arms)-1)
+		minPower := 1.0
+		for i := 1; i < len(arms); i++ {
+			p, err := stats.PowerTwoProportions(req.Baseline, req.Baseline+req.MDE, counts[0], counts[i], req.AdjustedAlpha())
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid design parameters")
+			}
+			if p < minPower {
+				minPower = p
+			}
+			achieved = append(achieved, fiber.Map{
+				"arm":           arms[i].Name,
+				"votes":         counts[i],
+				"control_votes": counts[0],
+				"power":         p,
+			})
+		}
+		resp["experiment_id"] = req.ExperimentID
+		resp["achieved"] = achieved
+		resp["achieved_power"] = minPower
+		return c.JSON(resp)
+	}
+}
+
+// SetExperimentTarget sets the sample each arm should reach, given directly
+// or computed from a power design. With auto_close each arm's poll stops
+// accepting votes once it reaches the target.
+func SetExperimentTarget(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		experiment, err := findOwnedExperiment(c, db)
+		if err != nil {
+			return err
+		}
+		arms, err := experimentArms(db, experiment.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+		}
+
+		var req struct {
+			TargetPerArm int          `json:"target_per_arm"`
+			Design       *powerDesign `json:"design"`
+			AutoClose    bool         `json:"auto_close"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		if req.Design != nil {
+			req.Design.Arms = len(arms)
+			if err := req.Design.validate(); err != nil {
+				return err
+			}
+			if req.TargetPerArm, err = req.Design.perArm(); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid design parameters")
+			}
+		}
+		if req.TargetPerArm < 1 {
+			return fiber.NewError(fiber.StatusBadRequest, "target_per_arm or design required")
+		}
+
+		maxVotes := 0
+		if req.AutoClose {
+			maxVotes = req.TargetPerArm
+		}
+		counts, err := armVoteCounts(db, arms)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to count votes")
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&experiment).Update("target_per_arm", req.TargetPerArm).Error; err != nil {
+				return err
+			}
+			for i, arm := range arms {
+				if err := tx.Model(&models.Poll{}).Where("id = ?", arm.PollID).Update("max_votes", maxVotes).Error; err != nil {
+					return err
+				}
+				if maxVotes > 0 && counts[i] >= maxVotes {
+					err := tx.Model(&models.Poll{}).Where("id = ? AND closed_at IS NULL", arm.PollID).Update("closed_at", time.Now()).Error
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to set target")
+		}
+		return c.JSON(fiber.Map{
+			"experiment_id":  experiment.ID,
+			"target_per_arm": req.TargetPerArm,
+			"auto_close":     req.AutoClose,
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"gorm.io/gorm"
+)
+
+// preregistrationDocument is the canonical form that is hashed. Field order
+// is fixed by the struct, so the same plan always hashes the same way.
+type preregistrationDocument struct {
+	Target       string `json:"target"` // "poll:<id>" or "experiment:<id>"
+	Hypotheses   string `json:"hypotheses"`
+	AnalysisPlan string `json:"analysis_plan"`
+	StoppingRule string `json:"stopping_rule"`
+	CommittedAt  string `json:"committed_at"` // RFC 3339, UTC
+}
+
+func commitment(document string) string {
+	sum := sha256.Sum256([]byte(document))
+	return hex.EncodeToString(sum[:])
+}
+
+// firstVoteAt returns when the earliest vote on any of the polls was cast,
+// or nil if there are none.
+func firstVoteAt(db *gorm.DB, pollIDs []uuid.UUID) (*time.Time, error) {
+	var first struct{ At *time.Time }
+	err := db.Model(&models.Vote{}).
+		Select("MIN(voted_at) as at").
+		Where("poll_id IN ?", pollIDs).
+		Scan(&first).Error
+	return first.At, err
+}
+
+// lockPreregistrations freezes the plans covering a poll, directly or via
+// its experiment, as its first vote is recorded.
+func lockPreregistrations(tx *gorm.DB, pollID uuid.UUID, experimentID *uuid.UUID, at time.Time) error {
+	q := tx.Model(&models.Preregistration{}).Where("locked_at IS NULL")
+	if experimentID != nil {
+		q = q.Where("poll_id = ? OR experiment_id = ?", pollID, *experimentID)
+	} else {
+		q = q.Where("poll_id = ?", pollID)
+	}
+	return q.Update("locked_at", at).Error
+}
+
+// savePreregistration registers (or, while unlocked and before any vote,
+// replaces) the plan for a poll or experiment.
+func savePreregistration(c *fiber.Ctx, db *gorm.DB, target string, pollIDs []uuid.UUID, where func(*gorm.DB) *gorm.DB, fill func(*models.Preregistration)) error {
+	var req struct {
+		Hypotheses   string `json:"hypotheses"`
+		AnalysisPlan string `json:"analysis_plan"`
+		StoppingRule string `json:"stopping_rule"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+	if req.Hypotheses == "" || req.AnalysisPlan == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Hypotheses and analysis_plan required")
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var prereg models.Preregistration
+	err = db.Transaction(func(tx *gorm.DB) error {
+		first, err := firstVoteAt(tx, pollIDs)
+		if err != nil {
+			return err
+		}
+		if first != nil {
+			return fiber.NewError(fiber.StatusConflict, "Data collection has already started")
+		}
+		err = where(tx).First(&prereg).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		if err == nil && prereg.LockedAt != nil {
+			return fiber.NewError(fiber.StatusConflict, "Preregistration is locked")
+		}
+		if err == gorm.ErrRecordNotFound {
+			prereg = models.Preregistration{ID: uuid.New()}
+			fill(&prereg)
+		}
+
+		now := time.Now().UTC()
+		doc, err := json.Marshal(preregistrationDocument{
+			Target:       target,
+			Hypotheses:   req.Hypotheses,
+			AnalysisPlan: req.AnalysisPlan,
+			StoppingRule: req.StoppingRule,
+			CommittedAt:  now.Format(time.RFC3339Nano),
+		})
+		if err != nil {
+			return err
+		}
+		prereg.CreatedBy = userID.String()
+		prereg.Document = string(doc)
+		prereg.Commitment = commitment(prereg.Document)
+		prereg.CommittedAt = now
+		return tx.Save(&prereg).Error
+	})
+	if err != nil {
+		if fe, ok := err.(*fiber.Error); ok {
+			return fe
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to save preregistration")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"preregistration_id": prereg.ID,
+		"commitment":         prereg.Commitment,
+		"committed_at":       prereg.CommittedAt,
+		"verify_url":         c.BaseURL() + "/preregistrations/" + prereg.ID.String(),
+	})
+}
+
+func PreregisterPoll(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		if poll.Mode == "pairwise" || poll.SurveyID != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Only standard polls can be preregistered")
+		}
+		return savePreregistration(c, db, "poll:"+poll.ID.String(), []uuid.UUID{poll.ID},
+			func(tx *gorm.DB) *gorm.DB { return tx.Where("poll_id = ?", poll.ID) },
+			func(p *models.Preregistration) { p.PollID = &poll.ID })
+	}
+}
+
+func PreregisterExperiment(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		experiment, err := findOwnedExperiment(c, db)
+		if err != nil {
+			return err
+		}
+		arms, err := experimentArms(db, experiment.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+		}
+		pollIDs := make([]uuid.UUID, len(arms))
+		for i, arm := range arms {
+			pollIDs[i] = arm.PollID
+		}
+		return savePreregistration(c, db, "experiment:"+experiment.ID.String(), pollIDs,
+			func(tx *gorm.DB) *gorm.DB { return tx.Where("experiment_id = ?", experiment.ID) },
+			func(p *models.Preregistration) { p.ExperimentID = &experiment.ID })
+	}
+}
+
+// VerifyPreregistration is public: it returns the registered document with
+// its commitment recomputed, and whether it was fixed before the first vote.
+func VerifyPreregistration(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("preregistration_id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid preregistration_id")
+		}
+		var prereg models.Preregistration
+		if err := db.Where("id = ?", id).First(&prereg).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fiber.NewError(fiber.StatusNotFound, "Preregistration not found")
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve preregistration")
+		}
+
+		var pollIDs []uuid.UUID
+		if prereg.PollID != nil {
+			pollIDs = append(pollIDs, *prereg.PollID)
+		} else if prereg.ExperimentID != nil {
+			arms, err := experimentArms(db, *prereg.ExperimentID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+			}
+			for _, arm := range arms {
+				pollIDs = append(pollIDs, arm.PollID)
+			}
+		}
+		first, err := firstVoteAt(db, pollIDs)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check votes")
+		}
+
+		recomputed := commitment(prereg.Document)
+		return c.JSON(fiber.Map{
+			"preregistration_id": prereg.ID,
+			"document":           prereg.Document,
+			"commitment":         prereg.Commitment,
+			"recomputed":         recomputed,
+			"intact":             recomputed == prereg.Commitment,
+			"committed_at":       prereg.CommittedAt,
+			"locked_at":          prereg.LockedAt,
+			"first_vote_at":      first,
+			"fixed_before_data":  first == nil || prereg.CommittedAt.Before(*first),
+			"hash_algorithm":     "SHA-256 of the document bytes exactly as returned",
+		})
+	}
+}
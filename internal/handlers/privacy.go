@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/privacy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// budgetSlack absorbs float rounding when summing spent epsilons.
+const budgetSlack = 1e-9
+
+// releaseInterval is how long a noisy release is served before the next
+// request pays for a fresh one. Releasing on a clock rather than when votes
+// arrive keeps the release history itself from revealing new votes.
+const releaseInterval = time.Hour
+
+type PrivacyView struct {
+	Mechanism       string    `json:"mechanism"`
+	Epsilon         float64   `json:"epsilon"`
+	Delta           float64   `json:"delta,omitempty"`
+	ReleasedAt      time.Time `json:"released_at"`
+	BudgetRemaining float64   `json:"budget_remaining"`
+}
+
+func encodeCounts(options []OptionResult, counts []int64) string {
+	pairs := make([]string, len(options))
+	for i, o := range options {
+		pairs[i] = o.OptionID + "=" + strconv.FormatInt(counts[i], 10)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// applyRelease replaces the tally's counts with a release's noisy ones.
+// Options created after the release show zero.
+func applyRelease(tally PollResults, release models.PrivacyRelease) PollResults {
+	noisy := map[string]int64{}
+	for _, pair := range strings.Split(release.Counts, ",") {
+		id, count, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		n, _ := strconv.ParseInt(count, 10, 64)
+		noisy[id] = n
+	}
+	tally.TotalVotes = 0
+	for i := range tally.Options {
+		tally.Options[i].Votes = noisy[tally.Options[i].OptionID]
+		tally.Options[i].Share = 0
+		tally.TotalVotes += tally.Options[i].Votes
+	}
+	if tally.TotalVotes > 0 {
+		for i := range tally.Options {
+			tally.Options[i].Share = float64(tally.Options[i].Votes) / float64(tally.TotalVotes)
+		}
+	}
+	return tally
+}
+
+func spentEpsilon(tx *gorm.DB, pollID uuid.UUID) (float64, error) {
+	var spent float64
+	err := tx.Model(&models.PrivacyRelease{}).
+		Select("COALESCE(SUM(epsilon), 0)").
+		Where("poll_id = ?", pollID).
+		Scan(&spent).Error
+	return spent, err
+}
+
+// privateResults returns the poll's latest noisy release, paying for a new
+// one from the budget once the latest is older than releaseInterval. Once the
+// budget is spent the last release is served indefinitely. The poll row is
+// locked so concurrent requests cannot overspend the budget.
+func privateResults(db *gorm.DB, poll models.Poll) (PollResults, PrivacyView, error) {
+	var results PollResults
+	var view PrivacyView
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", poll.ID).First(&poll).Error; err != nil {
+			return err
+		}
+		tally, err := tallyPoll(tx, poll.ID)
+		if err != nil {
+			return err
+		}
+		spent, err := spentEpsilon(tx, poll.ID)
+		if err != nil {
+			return err
+		}
+
+		var latest models.PrivacyRelease
+		err = tx.Where("poll_id = ?", poll.ID).Order("released_at DESC").First(&latest).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		hasLatest := err == nil
+
+		release := latest
+		affordable := spent+poll.PrivacyEpsilon <= poll.PrivacyBudget+budgetSlack
+		if !hasLatest && !affordable {
+			return fiber.NewError(fiber.StatusConflict, "Privacy budget exhausted")
+		}
+		if affordable && (!hasLatest || time.Since(latest.ReleasedAt) >= releaseInterval) {
+			counts := make([]int64, len(tally.Options))
+			for i, o := range tally.Options {
+				counts[i] = o.Votes
+			}
+			noisy, err := privacy.NoisyCounts(counts, poll.PrivacyMechanism, poll.PrivacyEpsilon, poll.PrivacyDelta)
+			if err != nil {
+				return err
+			}
+			release = models.PrivacyRelease{
+				ID:         uuid.New(),
+				PollID:     poll.ID,
+				Mechanism:  poll.PrivacyMechanism,
+				Epsilon:    poll.PrivacyEpsilon,
+				Delta:      poll.PrivacyDelta,
+				TotalVotes: tally.TotalVotes,
+				Counts:     encodeCounts(tally.Options, noisy),
+				ReleasedAt: time.Now(),
+			}
+			if err := tx.Create(&release).Error; err != nil {
+				return err
+			}
+			spent += release.Epsilon
+		}
+
+		results = applyRelease(tally, release)
+		view.Mechanism = release.Mechanism
+		view.Epsilon = release.Epsilon
+		view.Delta = release.Delta
+		view.ReleasedAt = release.ReleasedAt
+		view.BudgetRemaining = poll.PrivacyBudget - spent
+		return nil
+	})
+	return results, view, err
+}
+
+// GetPrivacyLedger lists every noisy release of a poll and the budget it
+// consumed.
+func GetPrivacyLedger(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		if poll.PrivacyBudget == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Poll has no privacy budget")
+		}
+		var releases []models.PrivacyRelease
+		if err := db.Where("poll_id = ?", poll.ID).Order("released_at").Find(&releases).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch releases")
+		}
+
+		var spent float64
+		ledger := make([]fiber.Map, len(releases))
+		for i, r := range releases {
+			spent += r.Epsilon
+			ledger[i] = fiber.Map{
+				"release_id":  r.ID,
+				"mechanism":   r.Mechanism,
+				"epsilon":     r.Epsilon,
+				"delta":       r.Delta,
+				"total_votes": r.TotalVotes,
+				"released_at": r.ReleasedAt,
+			}
+		}
+		return c.JSON(fiber.Map{
+			"poll_id":          poll.ID,
+			"mechanism":        poll.PrivacyMechanism,
+			"budget":           poll.PrivacyBudget,
+			"epsilon_per_view": poll.PrivacyEpsilon,
+			"delta":            poll.PrivacyDelta,
+			"spent":            spent,
+			"remaining":        poll.PrivacyBudget - spent,
+			"releases":         ledger,
+		})
+	}
+}
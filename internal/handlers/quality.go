@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// Votes faster than this after first fetching the poll are implausible.
+	minPlausibleLatencyMs = 1500
+	// Submitted surveys averaging less than this per question are too fast.
+	minSecondsPerQuestion = 2.0
+	defaultMinQuality     = 0.5
+)
+
+// QualityScore rates one respondent between 0 and 1 from failed attention
+// checks (weight 0.6), straight-lining (0.2) and implausibly fast answers
+// (0.2).
+type QualityScore struct {
+	UserID         string  `json:"user_id"`
+	Answers        int     `json:"answers"`
+	ChecksTaken    int     `json:"checks_taken"`
+	ChecksFailed   int     `json:"checks_failed"`
+	StraightLining float64 `json:"straight_lining"` // 0 when answers vary, 1 when all positions match
+	FastShare      float64 `json:"fast_share"`
+	Score          float64 `json:"score"`
+	LowQuality     bool    `json:"low_quality"`
+}
+
+// qualityScope returns the polls a respondent's quality is judged over:
+// every question of the poll's survey, or just the poll itself.
+func qualityScope(db *gorm.DB, poll models.Poll) ([]uuid.UUID, error) {
+	if poll.SurveyID == nil {
+		return []uuid.UUID{poll.ID}, nil
+	}
+	questions, err := surveyQuestions(db, *poll.SurveyID)
+	if err != nil {
+		return nil, err
+	}
+	pollIDs := make([]uuid.UUID, len(questions))
+	for i, q := range questions {
+		pollIDs[i] = q.PollID
+	}
+	return pollIDs, nil
+}
+
+// respondentQuality scores everyone who voted on the given polls, lowest
+// score first. surveyID, if set, also judges submission speed.
+func respondentQuality(db *gorm.DB, pollIDs []uuid.UUID, surveyID *uuid.UUID, minQuality float64) ([]QualityScore, error) {
+	var checks []models.Poll
+	if err := db.Where("id IN ? AND correct_option_id IS NOT NULL", pollIDs).Find(&checks).Error; err != nil {
+		return nil, err
+	}
+	correct := make(map[uuid.UUID]uuid.UUID, len(checks))
+	for _, p := range checks {
+		correct[p.ID] = *p.CorrectOptionID
+	}
+
+	var votes []struct {
+		UserID    uuid.UUID
+		PollID    uuid.UUID
+		OptionID  uuid.UUID
+		LatencyMs *int64
+		Position  int
+	}
+	err := db.Table("votes").
+		Select("votes.user_id, votes.poll_id, votes.option_id, votes.latency_ms, poll_options.position").
+		Joins("JOIN poll_options ON poll_options.id = votes.option_id").
+		Where("votes.poll_id IN ?", pollIDs).
+		Scan(&votes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	slowSurvey := map[uuid.UUID]bool{}
+	fastSurvey := map[uuid.UUID]bool{}
+	if surveyID != nil {
+		var responses []models.SurveyResponse
+		if err := db.Where("survey_id = ? AND submitted_at IS NOT NULL", *surveyID).Find(&responses).Error; err != nil {
+			return nil, err
+		}
+		answered := map[uuid.UUID]int{}
+		for _, v := range votes {
+			answered[v.UserID]++
+		}
+		for _, r := range responses {
+			if n := answered[r.UserID]; n > 0 {
+				perQuestion := r.SubmittedAt.Sub(r.StartedAt).Seconds() / float64(n)
+				fastSurvey[r.UserID] = perQuestion < minSecondsPerQuestion
+				slowSurvey[r.UserID] = !fastSurvey[r.UserID]
+			}
+		}
+	}
+
+	type tally struct {
+		score     QualityScore
+		positions map[int]int
+		timed     int
+		fast      int
+	}
+	byUser := map[uuid.UUID]*tally{}
+	for _, v := range votes {
+		t := byUser[v.UserID]
+		if t == nil {
+			t = &tally{score: QualityScore{UserID: v.UserID.String()}, positions: map[int]int{}}
+			byUser[v.UserID] = t
+		}
+		t.score.Answers++
+		if want, ok := correct[v.PollID]; ok {
+			t.score.ChecksTaken++
+			if v.OptionID != want {
+				t.score.ChecksFailed++
+			}
+			continue
+		}
+		t.positions[v.Position]++
+		if v.LatencyMs != nil {
+			t.timed++
+			if *v.LatencyMs < minPlausibleLatencyMs {
+				t.fast++
+			}
+		}
+	}
+
+	scores := make([]QualityScore, 0, len(byUser))
+	for userID, t := range byUser {
+		s := t.score
+		var failRate float64
+		if s.ChecksTaken > 0 {
+			failRate = float64(s.ChecksFailed) / float64(s.ChecksTaken)
+		}
+		// Straight-lining only shows across several substantive answers;
+		// a modal share of one half or less counts as varied.
+		substantive := s.Answers - s.ChecksTaken
+		if substantive >= 3 {
+			var modal int
+			for _, n := range t.positions {
+				if n > modal {
+					modal = n
+				}
+			}
+			share := float64(modal) / float64(substantive)
+			if share > 0.5 {
+				s.StraightLining = (share - 0.5) / 0.5
+			}
+		}
+		switch {
+		case fastSurvey[userID]:
+			s.FastShare = 1
+		case slowSurvey[userID]:
+			s.FastShare = 0
+		case t.timed > 0:
+			s.FastShare = float64(t.fast) / float64(t.timed)
+		}
+		s.Score = 1 - 0.6*failRate - 0.2*s.StraightLining - 0.2*s.FastShare
+		s.LowQuality = s.Score < minQuality
+		scores = append(scores, s)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score < scores[j].Score
+		}
+		return scores[i].UserID < scores[j].UserID
+	})
+	return scores, nil
+}
+
+// minQuality reads the min_quality query parameter.
+func minQuality(c *fiber.Ctx) (float64, error) {
+	q := c.QueryFloat("min_quality", defaultMinQuality)
+	if q < 0 || q > 1 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "min_quality must be between 0 and 1")
+	}
+	return q, nil
+}
+
+// excludedRespondents returns the low-quality respondents to leave out of
+// results when the request asks for exclude_low_quality, or nil otherwise.
+func excludedRespondents(c *fiber.Ctx, db *gorm.DB, pollIDs []uuid.UUID, surveyID *uuid.UUID) ([]uuid.UUID, error) {
+	if !c.QueryBool("exclude_low_quality") {
+		return nil, nil
+	}
+	threshold, err := minQuality(c)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := respondentQuality(db, pollIDs, surveyID, threshold)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to score respondents")
+	}
+	excluded := []uuid.UUID{}
+	for _, s := range scores {
+		if s.LowQuality {
+			id, _ := uuid.Parse(s.UserID)
+			excluded = append(excluded, id)
+		}
+	}
+	return excluded, nil
+}
+
+// SetAttentionCheck marks a poll as an attention check with a known correct
+// option, or clears the mark when correct_option_id is empty.
+func SetAttentionCheck(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			CorrectOptionID string `json:"correct_option_id"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+
+		var correct *uuid.UUID
+		if req.CorrectOptionID != "" {
+			optionID, err := uuid.Parse(req.CorrectOptionID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid correct_option_id")
+			}
+			if err := db.Where("id = ? AND poll_id = ?", optionID, poll.ID).First(&models.PollOption{}).Error; err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Option does not belong to poll")
+			}
+			correct = &optionID
+		}
+		if err := db.Model(&poll).Update("correct_option_id", correct).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to update poll")
+		}
+		return c.JSON(fiber.Map{"poll_id": poll.ID, "attention_check": correct != nil, "correct_option_id": correct})
+	}
+}
+
+// GetSurveyQuality lists every respondent's quality score, worst first.
+func GetSurveyQuality(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		survey, err := findOwnedSurvey(c, db)
+		if err != nil {
+			return err
+		}
+		threshold, err := minQuality(c)
+		if err != nil {
+			return err
+		}
+		questions, err := surveyQuestions(db, survey.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch questions")
+		}
+		pollIDs := make([]uuid.UUID, len(questions))
+		for i, q := range questions {
+			pollIDs[i] = q.PollID
+		}
+		scores, err := respondentQuality(db, pollIDs, &survey.ID, threshold)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to score respondents")
+		}
+		var low int
+		for _, s := range scores {
+			if s.LowQuality {
+				low++
+			}
+		}
+		return c.JSON(fiber.Map{
+			"survey_id":   survey.ID,
+			"min_quality": threshold,
+			"respondents": len(scores),
+			"low_quality": low,
+			"scores":      scores,
+		})
+	}
+}
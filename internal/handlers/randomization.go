@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/phone"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ageGroups = map[string]bool{
+	"18-24": true, "25-34": true, "35-44": true, "45-54": true, "55-64": true, "65+": true,
+}
+
+// errAlreadyAssigned aborts a block draw when a concurrent request assigned
+// the participant first, so the drawn slot is not consumed.
+var errAlreadyAssigned = errors.New("participant already assigned")
+
+func stratifyKeys(experiment models.Experiment) []string {
+	var keys []string
+	for _, k := range strings.Split(experiment.StratifyBy, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// stratumFor builds the participant's stratum label, e.g.
+// "country=IN|age_group=25-34". Country comes from the phone number the
+// user signed in with; age group is self-reported in attrs.
+func stratumFor(db *gorm.DB, experiment models.Experiment, userID uuid.UUID, attrs map[string]string) (string, error) {
+	keys := stratifyKeys(experiment)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		var value string
+		switch key {
+		case "country":
+			var user models.User
+			if err := db.Where("id = ?", userID).First(&user).Error; err != nil && err != gorm.ErrRecordNotFound {
+				return "", err
+			}
+			value = phone.CountryCode(user.Identifier)
+			if value == "" {
+				value = "unknown"
+			}
+		case "age_group":
+			value = attrs["age_group"]
+			if !ageGroups[value] {
+				return "", fiber.NewError(fiber.StatusBadRequest, "age_group required (18-24, 25-34, 35-44, 45-54, 55-64 or 65+); fetch /experiments/:experiment_id/assignment first")
+			}
+		}
+		parts = append(parts, key+"="+value)
+	}
+	return strings.Join(parts, "|"), nil
+}
+
+// blockComposition returns how many slots each arm gets in one block: arm
+// weights are rounded to integers and scaled up to the block size.
+func blockComposition(arms []models.ExperimentArm, blockSize int) ([]int, error) {
+	counts := make([]int, len(arms))
+	var cycle int
+	for i, arm := range arms {
+		counts[i] = int(math.Round(arm.Weight))
+		if counts[i] < 1 {
+			return nil, errors.New("block randomization needs integer weights of at least 1")
+		}
+		cycle += counts[i]
+	}
+	if blockSize%cycle != 0 {
+		return nil, errors.New("block size must be a multiple of the sum of arm weights")
+	}
+	for i := range counts {
+		counts[i] *= blockSize / cycle
+	}
+	return counts, nil
+}
+
+func newBlockSequence(arms []models.ExperimentArm, blockSize int) (string, error) {
+	counts, err := blockComposition(arms, blockSize)
+	if err != nil {
+		return "", err
+	}
+	seq := make([]string, 0, blockSize)
+	for i, arm := range arms {
+		for n := 0; n < counts[i]; n++ {
+			seq = append(seq, arm.ID.String())
+		}
+	}
+	rand.Shuffle(len(seq), func(i, j int) { seq[i], seq[j] = seq[j], seq[i] })
+	return strings.Join(seq, ","), nil
+}
+
+// assignFromBlock draws the next slot of the stratum's current block and
+// records the assignment. The block row is locked with SELECT ... FOR UPDATE
+// so concurrent API processes hand out each slot exactly once.
+func assignFromBlock(db *gorm.DB, experiment models.Experiment, arms []models.ExperimentArm, userID uuid.UUID, stratum string) (models.ExperimentAssignment, error) {
+	var assignment models.ExperimentAssignment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		seed := models.ExperimentBlock{
+			ID:           uuid.New(),
+			ExperimentID: experiment.ID,
+			Stratum:      stratum,
+			UpdatedAt:    time.Now(),
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seed).Error; err != nil {
+			return err
+		}
+		var block models.ExperimentBlock
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("experiment_id = ? AND stratum = ?", experiment.ID, stratum).
+			First(&block).Error
+		if err != nil {
+			return err
+		}
+
+		seq := strings.Split(block.Sequence, ",")
+		if block.Sequence == "" || block.Next >= len(seq) {
+			sequence, err := newBlockSequence(arms, experiment.BlockSize)
+			if err != nil {
+				return err
+			}
+			block.Sequence = sequence
+			block.BlockNumber++
+			block.Next = 0
+			seq = strings.Split(sequence, ",")
+		}
+		armID, err := uuid.Parse(seq[block.Next])
+		if err != nil {
+			return err
+		}
+
+		assignment = models.ExperimentAssignment{
+			ID:           uuid.New(),
+			ExperimentID: experiment.ID,
+			ArmID:        armID,
+			UserID:       userID,
+			Stratum:      stratum,
+			AssignedAt:   time.Now(),
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignment)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errAlreadyAssigned
+		}
+
+		block.Next++
+		block.UpdatedAt = time.Now()
+		return tx.Save(&block).Error
+	})
+	if err == errAlreadyAssigned {
+		err = db.Where("experiment_id = ? AND user_id = ?", experiment.ID, userID).First(&assignment).Error
+	}
+	return assignment, err
+}
+
+// GetExperimentBalance reports how many participants each arm received
+// within every stratum.
+func GetExperimentBalance(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		experiment, err := findOwnedExperiment(c, db)
+		if err != nil {
+			return err
+		}
+		arms, err := experimentArms(db, experiment.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch arms")
+		}
+		armNames := make(map[uuid.UUID]string, len(arms))
+		for _, arm := range arms {
+			armNames[arm.ID] = arm.Name
+		}
+
+		var rows []struct {
+			Stratum string
+			ArmID   uuid.UUID
+			Count   int64
+		}
+		err = db.Model(&models.ExperimentAssignment{}).
+			Select("stratum, arm_id, count(*) as count").
+			Where("experiment_id = ?", experiment.ID).
+			Group("stratum, arm_id").
+			Scan(&rows).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to count assignments")
+		}
+
+		byStratum := make(map[string]map[string]int64)
+		for _, r := range rows {
+			if byStratum[r.Stratum] == nil {
+				byStratum[r.Stratum] = make(map[string]int64, len(arms))
+				for _, arm := range arms {
+					byStratum[r.Stratum][arm.Name] = 0
+				}
+			}
+			byStratum[r.Stratum][armNames[r.ArmID]] += r.Count
+		}
+		strata := make([]fiber.Map, 0, len(byStratum))
+		for stratum, counts := range byStratum {
+			strata = append(strata, fiber.Map{"stratum": stratum, "arms": counts})
+		}
+		sort.Slice(strata, func(i, j int) bool {
+			return strata[i]["stratum"].(string) < strata[j]["stratum"].(string)
+		})
+
+		return c.JSON(fiber.Map{
+			"experiment_id": experiment.ID,
+			"randomization": experiment.Randomization,
+			"block_size":    experiment.BlockSize,
+			"stratify_by":   stratifyKeys(experiment),
+			"strata":        strata,
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/randomize"
+	"github.com/gopro/internal/stats"
+)
+
+// Defaults for the forced-response design: answer truthfully with
+// probability 3/4, otherwise give the forced answer, "yes" half the time.
+const (
+	defaultTruthProbability     = 0.75
+	defaultForcedYesProbability = 0.5
+)
+
+// RandomizedResponseEstimate is the de-biased share of "yes" in the
+// population, recovered from the reported answers.
+type RandomizedResponseEstimate struct {
+	TruthProbability     float64 `json:"truth_probability"`
+	ForcedYesProbability float64 `json:"forced_yes_probability"`
+	Reported             int64   `json:"reported"`
+	ReportedYesShare     float64 `json:"reported_yes_share"`
+	Estimate             float64 `json:"estimate"`
+	StdError             float64 `json:"std_error"`
+	Level                float64 `json:"level"`
+	Lower                float64 `json:"ci_lower"`
+	Upper                float64 `json:"ci_upper"`
+}
+
+// newInstructionKey returns the random per-poll secret that keys
+// drawInstruction. It is stored only on the poll row and never returned by
+// the API, so neither respondents nor the poll owner can recompute whose
+// reported answer was forced. Anyone with database access still can.
+func newInstructionKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// drawInstruction tells a respondent how to answer: "truthful",
+// "forced_yes" or "forced_no". The draw is fixed per poll and respondent so
+// refetching cannot change it; see newInstructionKey for who can recompute it.
+func drawInstruction(poll models.Poll, userID uuid.UUID) string {
+	u := randomize.Unit(poll.InstructionKey, poll.ID.String(), userID.String(), "instruction")
+	switch {
+	case u < poll.TruthProbability:
+		return "truthful"
+	case u < poll.TruthProbability+(1-poll.TruthProbability)*poll.ForcedYesProbability:
+		return "forced_yes"
+	default:
+		return "forced_no"
+	}
+}
+
+// randomizedResponseEstimate inverts the design. The reported yes share is
+// lambda = p*pi + (1-p)*q, so pi = (lambda - (1-p)*q) / p with standard
+// error sqrt(lambda*(1-lambda)/n) / p. The first option is "yes".
+func randomizedResponseEstimate(poll models.Poll, tally PollResults, level float64) RandomizedResponseEstimate {
+	p, q := poll.TruthProbability, poll.ForcedYesProbability
+	est := RandomizedResponseEstimate{
+		TruthProbability:     p,
+		ForcedYesProbability: q,
+		Reported:             tally.TotalVotes,
+		Level:                level,
+	}
+	if tally.TotalVotes == 0 || len(tally.Options) == 0 {
+		return est
+	}
+	n := float64(tally.TotalVotes)
+	lambda := float64(tally.Options[0].Votes) / n
+	est.ReportedYesShare = lambda
+	est.Estimate = clamp01((lambda - (1-p)*q) / p)
+	est.StdError = math.Sqrt(lambda*(1-lambda)/n) / p
+	z := stats.NormalQuantile(1 - (1-level)/2)
+	raw := (lambda - (1-p)*q) / p
+	est.Lower = clamp01(raw - z*est.StdError)
+	est.Upper = clamp01(raw + z*est.StdError)
+	return est
+}
+
+func clamp01(x float64) float64 {
+	return math.Min(1, math.Max(0, x))
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RecurringPollView struct {
+	ID          string     `json:"recurring_poll_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Options     []string   `json:"options"`
+	Cron        string     `json:"cron"`
+	Notify      bool       `json:"notify_subscribers"`
+	Active      bool       `json:"active"`
+	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt   *time.Time `json:"last_run_at"`
+	Subscribed  bool       `json:"subscribed"`
+}
+
+// SeriesPoint is the tally of one instance of a recurring poll.
+type SeriesPoint struct {
+	PollID     string         `json:"poll_id"`
+	OpenedAt   time.Time      `json:"opened_at"`
+	TotalVotes int64          `json:"total_votes"`
+	Options    []OptionResult `json:"options"`
+}
+
+func findRecurringPoll(c *fiber.Ctx, db *gorm.DB) (models.RecurringPoll, error) {
+	var template models.RecurringPoll
+	id, err := uuid.Parse(c.Params("recurring_id"))
+	if err != nil {
+		return template, fiber.NewError(fiber.StatusBadRequest, "Invalid recurring_id")
+	}
+	if err := db.Where("id = ?", id).First(&template).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return template, fiber.NewError(fiber.StatusNotFound, "Recurring poll not found")
+		}
+		return template, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve recurring poll")
+	}
+	return template, nil
+}
+
+func findOwnedRecurringPoll(c *fiber.Ctx, db *gorm.DB) (models.RecurringPoll, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return models.RecurringPoll{}, err
+	}
+	template, err := findRecurringPoll(c, db)
+	if err != nil {
+		return template, err
+	}
+	if template.CreatedBy != userID.String() {
+		return template, fiber.NewError(fiber.StatusForbidden, "Only the recurring poll owner can do this")
+	}
+	return template, nil
+}
+
+func recurringPollView(db *gorm.DB, template models.RecurringPoll, userID uuid.UUID) (RecurringPollView, error) {
+	view := RecurringPollView{
+		ID:          template.ID.String(),
+		Title:       template.Title,
+		Description: template.Description,
+		Options:     splitList(template.Options),
+		Cron:        template.Cronspec,
+		Notify:      template.Notify,
+		Active:      template.Active,
+		LastRunAt:   template.LastRunAt,
+	}
+	if schedule, err := cron.ParseStandard(template.Cronspec); err == nil && template.Active {
+		next := schedule.Next(time.Now())
+		view.NextRunAt = &next
+	}
+	var count int64
+	err := db.Model(&models.RecurringSubscription{}).
+		Where("recurring_poll_id = ? AND user_id = ?", template.ID, userID).
+		Count(&count).Error
+	view.Subscribed = count > 0
+	return view, err
+}
+
+// CreateRecurringPoll saves a poll template that the worker opens as a new
+// poll every time the cron expression fires.
+func CreateRecurringPoll(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			PollName    string   `json:"poll_name"`
+			Title       string   `json:"title"`
+			Description string   `json:"description"`
+			Options     []string `json:"options"`
+			// Cron is a standard 5-field expression, e.g. "0 9 * * 1-5";
+			// prefix it with "CRON_TZ=Europe/Berlin " for a time zone.
+			Cron   string `json:"cron"`
+			Notify bool   `json:"notify_subscribers"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		if req.Title == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Title required")
+		}
+		if len(req.Options) < 2 {
+			return fiber.NewError(fiber.StatusBadRequest, "At least 2 options required")
+		}
+		for _, opt := range req.Options {
+			if opt == "" || strings.Contains(opt, ",") {
+				return fiber.NewError(fiber.StatusBadRequest, "Options must be non-empty and contain no commas")
+			}
+		}
+		if _, err := cron.ParseStandard(req.Cron); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid cron expression")
+		}
+
+		template := models.RecurringPoll{
+			ID:          uuid.New(),
+			WebsiteID:   req.PollName,
+			Title:       req.Title,
+			Description: req.Description,
+			Options:     strings.Join(req.Options, ","),
+			Cronspec:    req.Cron,
+			LinkBase:    c.BaseURL(),
+			Notify:      req.Notify,
+			Active:      true,
+			CreatedBy:   userID.String(),
+			CreatedAt:   time.Now(),
+		}
+		if err := db.Create(&template).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create recurring poll")
+		}
+		view, err := recurringPollView(db, template, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check subscription")
+		}
+		return c.Status(fiber.StatusCreated).JSON(view)
+	}
+}
+
+func GetRecurringPoll(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		template, err := findRecurringPoll(c, db)
+		if err != nil {
+			return err
+		}
+		view, err := recurringPollView(db, template, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check subscription")
+		}
+		return c.JSON(view)
+	}
+}
+
+// SetRecurringPollActive pauses or resumes a schedule. The worker picks up
+// the change on its next sync.
+func SetRecurringPollActive(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		template, err := findOwnedRecurringPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			Active *bool `json:"active"`
+		}
+		if err := c.BodyParser(&req); err != nil || req.Active == nil {
+			return fiber.NewError(fiber.StatusBadRequest, "active required")
+		}
+		if err := db.Model(&template).Update("active", *req.Active).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to update recurring poll")
+		}
+		return c.JSON(fiber.Map{"recurring_poll_id": template.ID, "active": *req.Active})
+	}
+}
+
+func SubscribeRecurringPoll(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		template, err := findRecurringPoll(c, db)
+		if err != nil {
+			return err
+		}
+		sub := models.RecurringSubscription{
+			ID:              uuid.New(),
+			RecurringPollID: template.ID,
+			UserID:          userID,
+			SubscribedAt:    time.Now(),
+		}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&sub).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to subscribe")
+		}
+		return c.JSON(fiber.Map{"recurring_poll_id": template.ID, "subscribed": true})
+	}
+}
+
+func UnsubscribeRecurringPoll(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		template, err := findRecurringPoll(c, db)
+		if err != nil {
+			return err
+		}
+		err = db.Where("recurring_poll_id = ? AND user_id = ?", template.ID, userID).
+			Delete(&models.RecurringSubscription{}).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to unsubscribe")
+		}
+		return c.JSON(fiber.Map{"recurring_poll_id": template.ID, "subscribed": false})
+	}
+}
+
+// GetRecurringSeries returns the tally of every instance, oldest first, so
+// answers to the same question can be followed over time.
+func GetRecurringSeries(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		template, err := findRecurringPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var polls []models.Poll
+		if err := db.Where("recurring_poll_id = ?", template.ID).Order("created_at").Find(&polls).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch instances")
+		}
+		series := make([]SeriesPoint, len(polls))
+		for i, p := range polls {
+			tally, err := tallyPoll(db, p.ID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to tally votes")
+			}
+			series[i] = SeriesPoint{
+				PollID:     p.ID.String(),
+				OpenedAt:   p.CreatedAt,
+				TotalVotes: tally.TotalVotes,
+				Options:    tally.Options,
+			}
+		}
+		return c.JSON(fiber.Map{
+			"recurring_poll_id": template.ID,
+			"title":             template.Title,
+			"series":            series,
+		})
+	}
+}
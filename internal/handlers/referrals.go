@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/stats"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CascadeNode is a participant in a poll's sharing tree. Referrers who
+// shared without voting appear as roots with no vote time.
+type CascadeNode struct {
+	UserID     string        `json:"user_id"`
+	VotedAt    *time.Time    `json:"voted_at"`
+	Generation int           `json:"generation"`
+	Children   []CascadeNode `json:"children,omitempty"`
+}
+
+// GenerationStats summarizes the voters at one depth of the cascade. Times
+// are in seconds.
+type GenerationStats struct {
+	Generation         int           `json:"generation"`
+	Voters             int           `json:"voters"`
+	SincePollCreated   stats.Summary `json:"since_poll_created"`
+	SinceReferrerVoted stats.Summary `json:"since_referrer_voted"`
+}
+
+type Spreader struct {
+	UserID           string `json:"user_id"`
+	DirectReferrals  int    `json:"direct_referrals"`
+	CascadeSize      int    `json:"cascade_size"` // voters reached through any number of hops
+	DeepestReachHops int    `json:"deepest_reach_hops"`
+}
+
+// referrer resolves a share token to the user who shared the poll. Unknown
+// tokens and people following their own link are not attributed.
+func referrer(db *gorm.DB, pollID, userID uuid.UUID, token string) (*uuid.UUID, error) {
+	if token == "" {
+		return nil, nil
+	}
+	var share models.ShareToken
+	err := db.Where("poll_id = ? AND token = ?", pollID, token).First(&share).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if share.UserID == userID {
+		return nil, nil
+	}
+	return &share.UserID, nil
+}
+
+// CreateShareLink returns the participant's personal link to the poll. Only
+// participants who have opened the poll may share it, so a referrer always
+// arrived before the people they refer and the cascade has no cycles.
+func CreateShareLink(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		poll, err := findPoll(c, db)
+		if err != nil {
+			return err
+		}
+		view, err := findView(db, poll.ID, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check poll view")
+		}
+		if view == nil {
+			return fiber.NewError(fiber.StatusConflict, "Open the poll before sharing it")
+		}
+
+		buf := make([]byte, 12)
+		if _, err := rand.Read(buf); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to generate share link")
+		}
+		share := models.ShareToken{
+			ID:        uuid.New(),
+			PollID:    poll.ID,
+			UserID:    userID,
+			Token:     base64.RawURLEncoding.EncodeToString(buf),
+			CreatedAt: time.Now(),
+		}
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&share)
+		if res.Error != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create share link")
+		}
+		if res.RowsAffected == 0 {
+			if err := db.Where("poll_id = ? AND user_id = ?", poll.ID, userID).First(&share).Error; err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch share link")
+			}
+		}
+		return c.JSON(fiber.Map{
+			"poll_id":    poll.ID,
+			"token":      share.Token,
+			"share_link": poll.ShareableLink + "?ref=" + share.Token,
+		})
+	}
+}
+
+// GetPollCascade reconstructs who brought whom to vote on a poll and
+// summarizes how far and how fast the poll spread.
+func GetPollCascade(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		limit := c.QueryInt("limit", 10)
+		if limit < 1 {
+			return fiber.NewError(fiber.StatusBadRequest, "limit must be positive")
+		}
+
+		var votes []models.Vote
+		if err := db.Where("poll_id = ?", poll.ID).Order("voted_at").Find(&votes).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch votes")
+		}
+
+		// A voter's first vote places them in the tree
+		type node struct {
+			votedAt    *time.Time
+			referredBy *uuid.UUID
+			children   []uuid.UUID
+		}
+		nodes := map[uuid.UUID]*node{}
+		var order []uuid.UUID
+		for _, v := range votes {
+			if _, ok := nodes[v.UserID]; ok {
+				continue
+			}
+			votedAt := v.VotedAt
+			nodes[v.UserID] = &node{votedAt: &votedAt, referredBy: v.ReferredBy}
+			order = append(order, v.UserID)
+		}
+		for _, id := range order {
+			parent := nodes[id].referredBy
+			if parent == nil {
+				continue
+			}
+			if _, ok := nodes[*parent]; !ok {
+				nodes[*parent] = &node{}
+				order = append(order, *parent)
+			}
+			nodes[*parent].children = append(nodes[*parent].children, id)
+		}
+
+		// Walk down from the roots, recording generations and the size of
+		// each subtree.
+		generation := map[uuid.UUID]int{}
+		size := map[uuid.UUID]int{}
+		reach := map[uuid.UUID]int{}
+		var build func(id uuid.UUID, gen int) CascadeNode
+		build = func(id uuid.UUID, gen int) CascadeNode {
+			n := nodes[id]
+			generation[id] = gen
+			out := CascadeNode{UserID: id.String(), VotedAt: n.votedAt, Generation: gen}
+			for _, child := range n.children {
+				if _, seen := generation[child]; seen {
+					continue
+				}
+				out.Children = append(out.Children, build(child, gen+1))
+				size[id] += size[child]
+				if nodes[child].votedAt != nil {
+					size[id]++
+				}
+				if reach[child]+1 > reach[id] {
+					reach[id] = reach[child] + 1
+				}
+			}
+			return out
+		}
+		var roots []CascadeNode
+		for _, id := range order {
+			if nodes[id].referredBy == nil {
+				roots = append(roots, build(id, 0))
+			}
+		}
+
+		// Per-generation timing and branching among voters
+		var voters, depth, referred, spreaders, referrals int
+		since := map[int][]float64{}
+		sinceParent := map[int][]float64{}
+		for _, id := range order {
+			n := nodes[id]
+			gen, ok := generation[id]
+			if !ok {
+				continue
+			}
+			if len(n.children) > 0 {
+				spreaders++
+				referrals += len(n.children)
+			}
+			if n.votedAt == nil {
+				continue
+			}
+			voters++
+			if gen > depth {
+				depth = gen
+			}
+			since[gen] = append(since[gen], n.votedAt.Sub(poll.CreatedAt).Seconds())
+			if n.referredBy != nil {
+				referred++
+				if parent := nodes[*n.referredBy]; parent.votedAt != nil {
+					sinceParent[gen] = append(sinceParent[gen], n.votedAt.Sub(*parent.votedAt).Seconds())
+				}
+			}
+		}
+		generations := make([]GenerationStats, 0, depth+1)
+		for gen := 0; gen <= depth; gen++ {
+			if len(since[gen]) == 0 {
+				continue
+			}
+			generations = append(generations, GenerationStats{
+				Generation:         gen,
+				Voters:             len(since[gen]),
+				SincePollCreated:   stats.Describe(since[gen]),
+				SinceReferrerVoted: stats.Describe(sinceParent[gen]),
+			})
+		}
+		var branching float64
+		if spreaders > 0 {
+			branching = float64(referrals) / float64(spreaders)
+		}
+
+		top := make([]Spreader, 0, spreaders)
+		for _, id := range order {
+			if n := nodes[id]; len(n.children) > 0 {
+				top = append(top, Spreader{
+					UserID:           id.String(),
+					DirectReferrals:  len(n.children),
+					CascadeSize:      size[id],
+					DeepestReachHops: reach[id],
+				})
+			}
+		}
+		sort.SliceStable(top, func(i, j int) bool {
+			if top[i].CascadeSize != top[j].CascadeSize {
+				return top[i].CascadeSize > top[j].CascadeSize
+			}
+			return top[i].DirectReferrals > top[j].DirectReferrals
+		})
+		if len(top) > limit {
+			top = top[:limit]
+		}
+
+		return c.JSON(fiber.Map{
+			"poll_id":          poll.ID,
+			"voters":           voters,
+			"referred_voters":  referred,
+			"depth":            depth,
+			"branching_factor": branching, // mean referrals per participant who referred anyone
+			"generations":      generations,
+			"top_spreaders":    top,
+			"tree":             roots,
+		})
+	}
+}
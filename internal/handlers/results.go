@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OptionResult struct {
+	OptionID   string  `json:"option_id"`
+	OptionText string  `json:"option_text"`
+	Position   int     `json:"position"`
+	Votes      int64   `json:"votes"`
+	Share      float64 `json:"share"`
+}
+
+type PollResults struct {
+	PollID     string         `json:"poll_id"`
+	TotalVotes int64          `json:"total_votes"`
+	Options    []OptionResult `json:"options"`
+	// OrderEffects is only reported for polls with shuffled options.
+	OrderEffects *OrderEffects `json:"order_effects,omitempty"`
+	// RandomizedResponse de-biases the "yes" share of randomized response polls.
+	RandomizedResponse *RandomizedResponseEstimate `json:"randomized_response,omitempty"`
+	// Privacy describes the noisy release shown instead of exact counts.
+	Privacy *PrivacyView `json:"privacy,omitempty"`
+	// ExcludedRespondents counts low-quality respondents left out on request.
+	ExcludedRespondents *int `json:"excluded_respondents,omitempty"`
+}
+
+// tallyPoll counts votes per option, including options with no votes.
+func tallyPoll(db *gorm.DB, pollID uuid.UUID) (PollResults, error) {
+	return tallyPollExcluding(db, pollID, nil)
+}
+
+// tallyPollExcluding is tallyPoll leaving out the votes of the given users.
+func tallyPollExcluding(db *gorm.DB, pollID uuid.UUID, excluded []uuid.UUID) (PollResults, error) {
+	var rows []struct {
+		OptionID   uuid.UUID
+		OptionText string
+		Position   int
+		Votes      int64
+	}
+	q := db.Table("poll_options").
+		Select("poll_options.id as option_id, poll_options.option_text, poll_options.position, count(votes.id) as votes")
+	if len(excluded) > 0 {
+		q = q.Joins("LEFT JOIN votes ON votes.option_id = poll_options.id AND votes.user_id NOT IN ?", excluded)
+	} else {
+		q = q.Joins("LEFT JOIN votes ON votes.option_id = poll_options.id")
+	}
+	err := q.
+		Where("poll_options.poll_id = ?", pollID).
+		Group("poll_options.id, poll_options.option_text, poll_options.position").
+		Order("poll_options.position").
+		Scan(&rows).Error
+	if err != nil {
+		return PollResults{}, err
+	}
+
+	results := PollResults{PollID: pollID.String(), Options: make([]OptionResult, 0, len(rows))}
+	for _, r := range rows {
+		results.TotalVotes += r.Votes
+		results.Options = append(results.Options, OptionResult{
+			OptionID:   r.OptionID.String(),
+			OptionText: r.OptionText,
+			Position:   r.Position,
+			Votes:      r.Votes,
+		})
+	}
+	if results.TotalVotes > 0 {
+		for i := range results.Options {
+			results.Options[i].Share = float64(results.Options[i].Votes) / float64(results.TotalVotes)
+		}
+	}
+	return results, nil
+}
+
+// GetPollResults returns aggregated vote counts for a poll. Polls with a
+// privacy budget show everyone but the owner a noisy release instead.
+// exclude_low_quality=true leaves out respondents scoring below min_quality.
+func GetPollResults(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findPoll(c, db)
+		if err != nil {
+			return err
+		}
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		if poll.PrivacyBudget > 0 && poll.CreatedBy != userID.String() {
+			results, view, err := privateResults(db, poll)
+			if err != nil {
+				if fe, ok := err.(*fiber.Error); ok {
+					return fe
+				}
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to release results")
+			}
+			results.Privacy = &view
+			return c.JSON(results)
+		}
+		if err := guardTallies(c, poll); err != nil {
+			return err
+		}
+
+		scope, err := qualityScope(db, poll)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch survey questions")
+		}
+		excluded, err := excludedRespondents(c, db, scope, poll.SurveyID)
+		if err != nil {
+			return err
+		}
+		results, err := tallyPollExcluding(db, poll.ID, excluded)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to tally votes")
+		}
+		if excluded != nil {
+			n := len(excluded)
+			results.ExcludedRespondents = &n
+		}
+		if poll.Mode == "randomized_response" {
+			level, err := credibleLevel(c)
+			if err != nil {
+				return err
+			}
+			estimate := randomizedResponseEstimate(poll, results, level)
+			results.RandomizedResponse = &estimate
+		}
+		if poll.ShuffleOptions {
+			results.OrderEffects, err = orderEffects(db, poll.ID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to tally order effects")
+			}
+		}
+		return c.JSON(results)
+	}
+}
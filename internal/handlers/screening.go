@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/phone"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const screenedOutMessage = "Thank you for your interest. Unfortunately you are not eligible for this poll."
+
+type ScreenerView struct {
+	ID             string   `json:"id"`
+	Kind           string   `json:"kind"`
+	Question       string   `json:"question,omitempty"`
+	Options        []string `json:"options,omitempty"`
+	Accept         []string `json:"accept,omitempty"`    // owner view only
+	Countries      []string `json:"countries,omitempty"` // owner view only
+	RequiredPollID string   `json:"required_poll_id,omitempty"`
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func pollScreeners(db *gorm.DB, pollID uuid.UUID) ([]models.Screener, error) {
+	var screeners []models.Screener
+	err := db.Where("poll_id = ?", pollID).Order("position").Find(&screeners).Error
+	return screeners, err
+}
+
+func findScreeningResult(db *gorm.DB, pollID, userID uuid.UUID) (*models.ScreeningResult, error) {
+	var result models.ScreeningResult
+	err := db.Where("poll_id = ? AND user_id = ?", pollID, userID).First(&result).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// checkEligible returns a fiber error unless the participant passed the
+// poll's screeners, or the poll has none.
+func checkEligible(db *gorm.DB, pollID, userID uuid.UUID) error {
+	var count int64
+	if err := db.Model(&models.Screener{}).Where("poll_id = ?", pollID).Count(&count).Error; err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to check eligibility")
+	}
+	if count == 0 {
+		return nil
+	}
+	result, err := findScreeningResult(db, pollID, userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to check eligibility")
+	}
+	if result == nil {
+		return fiber.NewError(fiber.StatusForbidden, "Complete the screener via /polls/:poll_id/screening first")
+	}
+	if !result.Eligible {
+		return fiber.NewError(fiber.StatusForbidden, screenedOutMessage)
+	}
+	return nil
+}
+
+// passes evaluates one screener for the participant.
+func passes(db *gorm.DB, s models.Screener, user models.User, answer string) (bool, error) {
+	switch s.Kind {
+	case "question":
+		for _, a := range splitList(s.Accept) {
+			if a == answer {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "country":
+		country := phone.CountryCode(user.Identifier)
+		for _, code := range splitList(s.Countries) {
+			if code == country {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "completed_poll":
+		if s.RequiredPollID == nil {
+			return false, nil
+		}
+		var count int64
+		err := db.Model(&models.Vote{}).Where("poll_id = ? AND user_id = ?", *s.RequiredPollID, user.ID).Count(&count).Error
+		return count > 0, err
+	}
+	return false, nil
+}
+
+// SetScreeners replaces a poll's screeners. Earlier screening outcomes were
+// decided by the old screeners, so they are cleared and participants are
+// screened again.
+func SetScreeners(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			Screeners []ScreenerView `json:"screeners"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+
+		screeners := make([]models.Screener, len(req.Screeners))
+		for i, v := range req.Screeners {
+			s := models.Screener{ID: uuid.New(), PollID: poll.ID, Kind: v.Kind, Position: i}
+			switch v.Kind {
+			case "question":
+				if v.Question == "" || len(v.Options) < 2 || len(v.Accept) == 0 {
+					return fiber.NewError(fiber.StatusBadRequest, "Question screeners need a question, at least 2 options and accepted answers")
+				}
+				for _, opt := range v.Options {
+					if opt == "" || strings.Contains(opt, ",") {
+						return fiber.NewError(fiber.StatusBadRequest, "Screener options must be non-empty and contain no commas")
+					}
+				}
+				for _, a := range v.Accept {
+					if !containsString(v.Options, a) {
+						return fiber.NewError(fiber.StatusBadRequest, "Accepted answer is not an option: "+a)
+					}
+				}
+				s.Question = v.Question
+				s.Options = strings.Join(v.Options, ",")
+				s.Accept = strings.Join(v.Accept, ",")
+			case "country":
+				if len(v.Countries) == 0 {
+					return fiber.NewError(fiber.StatusBadRequest, "Country screeners need countries")
+				}
+				for j, code := range v.Countries {
+					v.Countries[j] = strings.ToUpper(strings.TrimSpace(code))
+				}
+				s.Countries = strings.Join(v.Countries, ",")
+			case "completed_poll":
+				requiredID, err := uuid.Parse(v.RequiredPollID)
+				if err != nil || requiredID == poll.ID {
+					return fiber.NewError(fiber.StatusBadRequest, "Invalid required_poll_id")
+				}
+				if err := db.Where("id = ?", requiredID).First(&models.Poll{}).Error; err != nil {
+					return fiber.NewError(fiber.StatusBadRequest, "Required poll not found")
+				}
+				s.RequiredPollID = &requiredID
+			default:
+				return fiber.NewError(fiber.StatusBadRequest, "Screener kind must be question, country or completed_poll")
+			}
+			screeners[i] = s
+		}
+
+		var cleared int64
+		err = db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Where("poll_id = ?", poll.ID).Delete(&models.ScreeningResult{})
+			if res.Error != nil {
+				return res.Error
+			}
+			cleared = res.RowsAffected
+			if err := tx.Where("poll_id = ?", poll.ID).Delete(&models.Screener{}).Error; err != nil {
+				return err
+			}
+			if len(screeners) == 0 {
+				return nil
+			}
+			return tx.Create(&screeners).Error
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to save screeners")
+		}
+		return c.JSON(fiber.Map{"poll_id": poll.ID, "screeners": len(screeners), "cleared_results": cleared})
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// GetScreeners lists the questions a participant must answer, and their
+// screening status. Only owners see the acceptance rules.
+func GetScreeners(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		poll, err := findPoll(c, db)
+		if err != nil {
+			return err
+		}
+		screeners, err := pollScreeners(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch screeners")
+		}
+		owner := poll.CreatedBy == userID.String()
+
+		views := make([]ScreenerView, 0, len(screeners))
+		for _, s := range screeners {
+			if s.Kind != "question" && !owner {
+				continue
+			}
+			v := ScreenerView{ID: s.ID.String(), Kind: s.Kind, Question: s.Question, Options: splitList(s.Options)}
+			if owner {
+				v.Accept = splitList(s.Accept)
+				v.Countries = splitList(s.Countries)
+				if s.RequiredPollID != nil {
+					v.RequiredPollID = s.RequiredPollID.String()
+				}
+			}
+			views = append(views, v)
+		}
+
+		status := "not_screened"
+		if len(screeners) == 0 {
+			status = "eligible"
+		} else if result, err := findScreeningResult(db, poll.ID, userID); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check eligibility")
+		} else if result != nil && result.Eligible {
+			status = "eligible"
+		} else if result != nil {
+			status = "ineligible"
+		}
+		return c.JSON(fiber.Map{"poll_id": poll.ID, "status": status, "screeners": views})
+	}
+}
+
+// SubmitScreening evaluates the participant against every screener and
+// records the outcome. Participants are screened once; resubmitting returns
+// the first outcome so answers cannot be changed to get in.
+func SubmitScreening(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		poll, err := findPoll(c, db)
+		if err != nil {
+			return err
+		}
+		var req struct {
+			Answers map[string]string `json:"answers"` // screener id -> chosen option
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+
+		existing, err := findScreeningResult(db, poll.ID, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check eligibility")
+		}
+		if existing != nil {
+			return screeningResponse(c, *existing)
+		}
+
+		screeners, err := pollScreeners(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch screeners")
+		}
+		if len(screeners) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Poll has no screeners")
+		}
+		var user models.User
+		if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch user")
+		}
+
+		answers := map[string]string{}
+		for _, s := range screeners {
+			if s.Kind != "question" {
+				continue
+			}
+			answer, ok := req.Answers[s.ID.String()]
+			if !ok || !containsString(splitList(s.Options), answer) {
+				return fiber.NewError(fiber.StatusBadRequest, "Answer every screener question with one of its options")
+			}
+			answers[s.ID.String()] = answer
+		}
+
+		result := models.ScreeningResult{
+			ID:         uuid.New(),
+			PollID:     poll.ID,
+			UserID:     userID,
+			Eligible:   true,
+			ScreenedAt: time.Now(),
+		}
+		for _, s := range screeners {
+			ok, err := passes(db, s, user, answers[s.ID.String()])
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to check eligibility")
+			}
+			if !ok {
+				result.Eligible = false
+				result.FailedScreenerID = &s.ID
+				break
+			}
+		}
+		encoded, _ := json.Marshal(answers)
+		result.Answers = string(encoded)
+
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&result)
+		if res.Error != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to record screening")
+		}
+		if res.RowsAffected == 0 {
+			if err := db.Where("poll_id = ? AND user_id = ?", poll.ID, userID).First(&result).Error; err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to record screening")
+			}
+		}
+		return screeningResponse(c, result)
+	}
+}
+
+func screeningResponse(c *fiber.Ctx, result models.ScreeningResult) error {
+	if !result.Eligible {
+		return c.JSON(fiber.Map{"eligible": false, "message": screenedOutMessage})
+	}
+	return c.JSON(fiber.Map{"eligible": true, "message": "You are eligible for this poll"})
+}
+
+// GetScreeningStats reports how many participants were screened out, and
+// by which screener.
+func GetScreeningStats(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		screeners, err := pollScreeners(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch screeners")
+		}
+
+		var rows []struct {
+			Eligible         bool
+			FailedScreenerID *uuid.UUID
+			Count            int64
+		}
+		err = db.Model(&models.ScreeningResult{}).
+			Select("eligible, failed_screener_id, count(*) as count").
+			Where("poll_id = ?", poll.ID).
+			Group("eligible, failed_screener_id").
+			Scan(&rows).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to count screenings")
+		}
+
+		var screened, eligible int64
+		failed := map[uuid.UUID]int64{}
+		for _, r := range rows {
+			screened += r.Count
+			if r.Eligible {
+				eligible += r.Count
+			} else if r.FailedScreenerID != nil {
+				failed[*r.FailedScreenerID] += r.Count
+			}
+		}
+		byScreener := make([]fiber.Map, len(screeners))
+		for i, s := range screeners {
+			byScreener[i] = fiber.Map{
+				"screener_id":  s.ID,
+				"kind":         s.Kind,
+				"question":     s.Question,
+				"screened_out": failed[s.ID],
+			}
+		}
+		var rate float64
+		if screened > 0 {
+			rate = float64(screened-eligible) / float64(screened)
+		}
+		return c.JSON(fiber.Map{
+			"poll_id":         poll.ID,
+			"screened":        screened,
+			"eligible":        eligible,
+			"screened_out":    screened - eligible,
+			"screen_out_rate": rate,
+			"by_screener":     byScreener,
+		})
+	}
+}
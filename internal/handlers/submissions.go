@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"gorm.io/gorm"
+)
+
+// SubmitOption lets an authenticated participant propose a new option while
+// the poll is in its submission phase.
+func SubmitOption(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		poll, err := findPoll(c, db)
+		if err != nil {
+			return err
+		}
+		if poll.Phase != "submission" {
+			return fiber.NewError(fiber.StatusConflict, "Poll is not accepting submissions")
+		}
+
+		var req struct {
+			Text string `json:"text"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		text := strings.TrimSpace(req.Text)
+		if text == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Option text required")
+		}
+
+		var existing int64
+		err = db.Model(&models.PollOption{}).
+			Where("poll_id = ? AND lower(option_text) = lower(?)", poll.ID, text).
+			Count(&existing).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check existing options")
+		}
+		if existing == 0 {
+			err = db.Model(&models.OptionSubmission{}).
+				Where("poll_id = ? AND status = ? AND lower(text) = lower(?)", poll.ID, "pending", text).
+				Count(&existing).Error
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to check existing submissions")
+			}
+		}
+		if existing > 0 {
+			return fiber.NewError(fiber.StatusConflict, "This option has already been proposed")
+		}
+
+		submission := models.OptionSubmission{
+			ID:          uuid.New(),
+			PollID:      poll.ID,
+			SubmittedBy: userID,
+			Text:        text,
+			Status:      "pending",
+			CreatedAt:   time.Now(),
+		}
+		if err := db.Create(&submission).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to submit option")
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(submission)
+	}
+}
+
+// ListSubmissions returns the options proposed for a poll, optionally
+// filtered by status. Only the poll owner may moderate submissions.
+func ListSubmissions(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+
+		query := db.Where("poll_id = ?", poll.ID)
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+		var submissions []models.OptionSubmission
+		if err := query.Order("created_at").Find(&submissions).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch submissions")
+		}
+
+		return c.JSON(submissions)
+	}
+}
+
+// ReviewSubmission approves or rejects a pending submission. Approved
+// submissions become votable options attributed to their submitter.
+func ReviewSubmission(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+		submissionID, err := uuid.Parse(c.Params("submission_id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid submission_id")
+		}
+
+		var req struct {
+			Decision string `json:"decision"` // "approve" or "reject"
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		if req.Decision != "approve" && req.Decision != "reject" {
+			return fiber.NewError(fiber.StatusBadRequest, "Decision must be approve or reject")
+		}
+
+		var submission models.OptionSubmission
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("id = ? AND poll_id = ?", submissionID, poll.ID).First(&submission).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return fiber.NewError(fiber.StatusNotFound, "Submission not found")
+				}
+				return err
+			}
+			if submission.Status != "pending" {
+				return fiber.NewError(fiber.StatusConflict, "Submission has already been reviewed")
+			}
+
+			now := time.Now()
+			submission.ReviewedAt = &now
+			submission.Status = "rejected"
+			if req.Decision == "approve" {
+				position, err := nextOptionPosition(tx, poll.ID)
+				if err != nil {
+					return err
+				}
+				submittedBy := submission.SubmittedBy
+				option := models.PollOption{
+					ID:          uuid.New(),
+					PollID:      poll.ID,
+					OptionText:  submission.Text,
+					SubmittedBy: &submittedBy,
+					Position:    position,
+				}
+				if err := tx.Create(&option).Error; err != nil {
+					return err
+				}
+				submission.Status = "approved"
+				submission.OptionID = &option.ID
+			}
+			return tx.Save(&submission).Error
+		})
+		if err != nil {
+			if fe, ok := err.(*fiber.Error); ok {
+				return fe
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to review submission")
+		}
+
+		return c.JSON(submission)
+	}
+}
+
+// SetPollPhase moves a poll between its submission and voting phases.
+func SetPollPhase(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+
+		var req struct {
+			Phase string `json:"phase"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		if req.Phase != "submission" && req.Phase != "voting" {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid phase")
+		}
+		if poll.Mode == "randomized_response" && req.Phase == "submission" {
+			return fiber.NewError(fiber.StatusConflict, "Randomized response polls have fixed yes/no options")
+		}
+		if req.Phase == "voting" {
+			var count int64
+			if err := db.Model(&models.PollOption{}).Where("poll_id = ?", poll.ID).Count(&count).Error; err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to count options")
+			}
+			if count < 2 {
+				return fiber.NewError(fiber.StatusConflict, "At least 2 approved options required to open voting")
+			}
+		}
+
+		if err := db.Model(&poll).Update("phase", req.Phase).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to update phase")
+		}
+		return c.JSON(fiber.Map{"poll_id": poll.ID, "phase": req.Phase})
+	}
+}
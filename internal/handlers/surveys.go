@@ -0,0 +1,524 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SurveyQuestionView struct {
+	PollID      string       `json:"poll_id"`
+	Position    int          `json:"position"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Options     []OptionView `json:"options"`
+}
+
+type SurveyResponseState struct {
+	SurveyID    string            `json:"survey_id"`
+	ResponseID  *string           `json:"response_id"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	UpdatedAt   *time.Time        `json:"updated_at,omitempty"`
+	SubmittedAt *time.Time        `json:"submitted_at,omitempty"`
+	Answers     map[string]string `json:"answers"`
+	Answered    int               `json:"answered"`
+	Total       int               `json:"total"`
+	Next        *string           `json:"next_question_id"`
+	Complete    bool              `json:"complete"`
+}
+
+func findSurvey(c *fiber.Ctx, db *gorm.DB) (models.Survey, error) {
+	var survey models.Survey
+	surveyID, err := uuid.Parse(c.Params("survey_id"))
+	if err != nil {
+		return survey, fiber.NewError(fiber.StatusBadRequest, "Invalid survey_id")
+	}
+	if err := db.Where("id = ?", surveyID).First(&survey).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return survey, fiber.NewError(fiber.StatusNotFound, "Survey not found")
+		}
+		return survey, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve survey")
+	}
+	return survey, nil
+}
+
+// surveyQuestions returns the survey's questions in display order.
+func surveyQuestions(db *gorm.DB, surveyID uuid.UUID) ([]models.SurveyQuestion, error) {
+	var questions []models.SurveyQuestion
+	err := db.Where("survey_id = ?", surveyID).Order("position").Find(&questions).Error
+	return questions, err
+}
+
+// CreateSurvey creates a survey together with one poll per question.
+func CreateSurvey(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+
+		var req struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Questions   []struct {
+				Title       string   `json:"title"`
+				Description string   `json:"description"`
+				Options     []string `json:"options"`
+			} `json:"questions"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		if len(req.Questions) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "At least 1 question required")
+		}
+		for _, q := range req.Questions {
+			if len(q.Options) < 2 {
+				return fiber.NewError(fiber.StatusBadRequest, "At least 2 options required for every question")
+			}
+		}
+
+		now := time.Now()
+		survey := models.Survey{
+			ID:          uuid.New(),
+			Title:       req.Title,
+			Description: req.Description,
+			CreatedBy:   userID.String(),
+			CreatedAt:   now,
+		}
+		questionIDs := make([]uuid.UUID, len(req.Questions))
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&survey).Error; err != nil {
+				return err
+			}
+			for i, q := range req.Questions {
+				pollID := uuid.New()
+				poll := models.Poll{
+					ID:            pollID,
+					Title:         q.Title,
+					Description:   q.Description,
+					CreatedBy:     survey.CreatedBy,
+					CreatedAt:     now,
+					ShareableLink: c.BaseURL() + "/poll/" + pollID.String(),
+					Mode:          "standard",
+					Phase:         "voting",
+					SurveyID:      &survey.ID,
+				}
+				if err := tx.Create(&poll).Error; err != nil {
+					return err
+				}
+				for j, opt := range q.Options {
+					option := models.PollOption{ID: uuid.New(), PollID: pollID, OptionText: opt, Position: j}
+					if err := tx.Create(&option).Error; err != nil {
+						return err
+					}
+				}
+				question := models.SurveyQuestion{ID: uuid.New(), SurveyID: survey.ID, PollID: pollID, Position: i}
+				if err := tx.Create(&question).Error; err != nil {
+					return err
+				}
+				questionIDs[i] = pollID
+			}
+			return nil
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create survey")
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"survey_id":    survey.ID,
+			"title":        survey.Title,
+			"description":  survey.Description,
+			"created_at":   survey.CreatedAt,
+			"created_by":   survey.CreatedBy,
+			"question_ids": questionIDs,
+		})
+	}
+}
+
+// GetSurvey returns a survey with its ordered questions and options.
+func GetSurvey(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		survey, err := findSurvey(c, db)
+		if err != nil {
+			return err
+		}
+		questions, err := surveyQuestions(db, survey.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch questions")
+		}
+
+		views := make([]SurveyQuestionView, 0, len(questions))
+		for _, q := range questions {
+			var poll models.Poll
+			if err := db.Where("id = ?", q.PollID).First(&poll).Error; err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch question")
+			}
+			options, err := pollOptions(db, q.PollID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch question options")
+			}
+			view := SurveyQuestionView{
+				PollID:      q.PollID.String(),
+				Position:    q.Position,
+				Title:       poll.Title,
+				Description: poll.Description,
+				Options:     make([]OptionView, 0, len(options)),
+			}
+			for _, opt := range options {
+				view.Options = append(view.Options, OptionView{ID: opt.ID.String(), Text: opt.OptionText})
+			}
+			views = append(views, view)
+		}
+
+		return c.JSON(fiber.Map{
+			"survey_id":   survey.ID,
+			"title":       survey.Title,
+			"description": survey.Description,
+			"created_at":  survey.CreatedAt,
+			"created_by":  survey.CreatedBy,
+			"questions":   views,
+		})
+	}
+}
+
+// loadSurveyResponse returns the user's response to a survey and its
+// answers. The response is nil if the user has not started the survey.
+func loadSurveyResponse(db *gorm.DB, surveyID, userID uuid.UUID) (*models.SurveyResponse, []models.SurveyAnswer, error) {
+	return findSurveyResponse(db, surveyID, userID, false)
+}
+
+// lockSurveyResponse is loadSurveyResponse holding a row lock on the
+// response until the transaction ends, so concurrent saves and submits of
+// the same response run one after another.
+func lockSurveyResponse(tx *gorm.DB, surveyID, userID uuid.UUID) (*models.SurveyResponse, []models.SurveyAnswer, error) {
+	return findSurveyResponse(tx, surveyID, userID, true)
+}
+
+func findSurveyResponse(db *gorm.DB, surveyID, userID uuid.UUID, lock bool) (*models.SurveyResponse, []models.SurveyAnswer, error) {
+	var response models.SurveyResponse
+	q := db.Where("survey_id = ? AND user_id = ?", surveyID, userID)
+	if lock {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	err := q.First(&response).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var answers []models.SurveyAnswer
+	if err := db.Where("response_id = ?", response.ID).Find(&answers).Error; err != nil {
+		return nil, nil, err
+	}
+	return &response, answers, nil
+}
+
+func surveyResponseState(surveyID uuid.UUID, plan *surveyPlan, response *models.SurveyResponse, answers []models.SurveyAnswer) SurveyResponseState {
+	w := plan.walk(answerMap(answers))
+	state := SurveyResponseState{
+		SurveyID: surveyID.String(),
+		Answers:  make(map[string]string, len(answers)),
+		Total:    len(w.path),
+		Complete: w.complete,
+	}
+	if w.next != nil {
+		next := w.next.String()
+		state.Next = &next
+	}
+	if response != nil {
+		responseID := response.ID.String()
+		state.ResponseID = &responseID
+		state.StartedAt = &response.StartedAt
+		state.UpdatedAt = &response.UpdatedAt
+		state.SubmittedAt = response.SubmittedAt
+	}
+	for _, a := range answers {
+		state.Answers[a.PollID.String()] = a.OptionID.String()
+	}
+	state.Answered = len(state.Answers)
+	return state
+}
+
+// GetSurveyResponse returns the caller's saved progress so they can resume.
+func GetSurveyResponse(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		survey, err := findSurvey(c, db)
+		if err != nil {
+			return err
+		}
+		plan, err := loadSurveyPlan(db, survey.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to load survey")
+		}
+		response, answers, err := loadSurveyResponse(db, survey.ID, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch response")
+		}
+		return c.JSON(surveyResponseState(survey.ID, plan, response, answers))
+	}
+}
+
+// SaveSurveyResponse stores answers for some or all questions, starting a
+// response session if the caller does not have one yet. Answers to questions
+// the branching rules would not show are rejected, and earlier answers that
+// fall off the path because of a changed answer are discarded.
+func SaveSurveyResponse(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		survey, err := findSurvey(c, db)
+		if err != nil {
+			return err
+		}
+
+		var req struct {
+			Answers map[string]string `json:"answers"` // question poll_id -> option_id
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+
+		plan, err := loadSurveyPlan(db, survey.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to load survey")
+		}
+		inSurvey := make(map[uuid.UUID]bool, len(plan.questions))
+		for _, q := range plan.questions {
+			inSurvey[q.PollID] = true
+		}
+		answers := make(map[uuid.UUID]uuid.UUID, len(req.Answers))
+		for pollIDStr, optionIDStr := range req.Answers {
+			pollID, err := uuid.Parse(pollIDStr)
+			if err != nil || !inSurvey[pollID] {
+				return fiber.NewError(fiber.StatusBadRequest, "Question does not belong to survey")
+			}
+			optionID, err := uuid.Parse(optionIDStr)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid option_id")
+			}
+			var count int64
+			if err := db.Model(&models.PollOption{}).Where("id = ? AND poll_id = ?", optionID, pollID).Count(&count).Error; err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to check option")
+			}
+			if count == 0 {
+				return fiber.NewError(fiber.StatusBadRequest, "Option does not belong to question")
+			}
+			answers[pollID] = optionID
+		}
+
+		now := time.Now()
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// Create the response if needed, then lock it; a concurrent
+			// first save may have created it already.
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SurveyResponse{
+				ID:        uuid.New(),
+				SurveyID:  survey.ID,
+				UserID:    userID,
+				StartedAt: now,
+				UpdatedAt: now,
+			}).Error
+			if err != nil {
+				return err
+			}
+			response, existing, err := lockSurveyResponse(tx, survey.ID, userID)
+			if err != nil {
+				return err
+			}
+			if response.SubmittedAt != nil {
+				return fiber.NewError(fiber.StatusConflict, "Survey response has already been submitted")
+			}
+
+			merged := answerMap(existing)
+			for pollID, optionID := range answers {
+				merged[pollID] = optionID
+			}
+			w := plan.walk(merged)
+			for pollID := range answers {
+				if !w.onPath[pollID] {
+					return fiber.NewError(fiber.StatusBadRequest, "Question "+pollID.String()+" should not be answered given earlier answers")
+				}
+			}
+			var stale []uuid.UUID
+			for _, a := range existing {
+				if !w.onPath[a.PollID] {
+					stale = append(stale, a.ID)
+				}
+			}
+			if len(stale) > 0 {
+				if err := tx.Where("id IN ?", stale).Delete(&models.SurveyAnswer{}).Error; err != nil {
+					return err
+				}
+			}
+
+			for pollID, optionID := range answers {
+				var answer models.SurveyAnswer
+				err := tx.Where("response_id = ? AND poll_id = ?", response.ID, pollID).First(&answer).Error
+				if err == gorm.ErrRecordNotFound {
+					answer = models.SurveyAnswer{ID: uuid.New(), ResponseID: response.ID, PollID: pollID}
+				} else if err != nil {
+					return err
+				}
+				answer.OptionID = optionID
+				answer.AnsweredAt = now
+				if err := tx.Save(&answer).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(response).Update("updated_at", now).Error
+		})
+		if err != nil {
+			if fe, ok := err.(*fiber.Error); ok {
+				return fe
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to save response")
+		}
+
+		response, saved, err := loadSurveyResponse(db, survey.ID, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch response")
+		}
+		return c.JSON(surveyResponseState(survey.ID, plan, response, saved))
+	}
+}
+
+// SubmitSurveyResponse finalizes the caller's response, recording one vote
+// per question on the participant's path through the survey.
+func SubmitSurveyResponse(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := currentUserID(c)
+		if err != nil {
+			return err
+		}
+		survey, err := findSurvey(c, db)
+		if err != nil {
+			return err
+		}
+		plan, err := loadSurveyPlan(db, survey.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to load survey")
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			response, answers, err := lockSurveyResponse(tx, survey.ID, userID)
+			if err != nil {
+				return err
+			}
+			if response == nil {
+				return fiber.NewError(fiber.StatusNotFound, "No response to submit")
+			}
+			if response.SubmittedAt != nil {
+				return fiber.NewError(fiber.StatusConflict, "Survey response has already been submitted")
+			}
+			w := plan.walk(answerMap(answers))
+			if !w.complete {
+				return fiber.NewError(fiber.StatusBadRequest, "All questions must be answered before submitting")
+			}
+
+			for _, a := range answers {
+				if !w.onPath[a.PollID] {
+					continue
+				}
+				var poll models.Poll
+				if err := tx.Where("id = ?", a.PollID).First(&poll).Error; err != nil {
+					return err
+				}
+				// Each question enforces the same rules as a direct vote
+				if err := checkVoter(tx, poll, userID); err != nil {
+					return err
+				}
+				vote := models.Vote{
+					ID:       uuid.New(),
+					PollID:   a.PollID,
+					OptionID: a.OptionID,
+					UserID:   userID,
+					VotedAt:  a.AnsweredAt,
+				}
+				if err := recordVote(tx, poll, &vote, nil); err != nil {
+					return err
+				}
+			}
+			now := time.Now()
+			return tx.Model(response).Update("submitted_at", &now).Error
+		})
+		if err != nil {
+			if fe, ok := err.(*fiber.Error); ok {
+				return fe
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to submit response")
+		}
+
+		return c.JSON(fiber.Map{"message": "Survey submitted successfully"})
+	}
+}
+
+// GetSurveyResults returns completion statistics and per-question tallies
+// of submitted responses.
+func GetSurveyResults(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		survey, err := findSurvey(c, db)
+		if err != nil {
+			return err
+		}
+		questions, err := surveyQuestions(db, survey.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch questions")
+		}
+
+		var started, submitted int64
+		if err := db.Model(&models.SurveyResponse{}).Where("survey_id = ?", survey.ID).Count(&started).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to count responses")
+		}
+		if err := db.Model(&models.SurveyResponse{}).Where("survey_id = ? AND submitted_at IS NOT NULL", survey.ID).Count(&submitted).Error; err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to count responses")
+		}
+		var completion float64
+		if started > 0 {
+			completion = float64(submitted) / float64(started)
+		}
+
+		pollIDs := make([]uuid.UUID, len(questions))
+		for i, q := range questions {
+			pollIDs[i] = q.PollID
+		}
+		excluded, err := excludedRespondents(c, db, pollIDs, &survey.ID)
+		if err != nil {
+			return err
+		}
+
+		perQuestion := make([]fiber.Map, 0, len(questions))
+		for _, q := range questions {
+			results, err := tallyPollExcluding(db, q.PollID, excluded)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to tally votes")
+			}
+			perQuestion = append(perQuestion, fiber.Map{
+				"position": q.Position,
+				"results":  results,
+			})
+		}
+
+		resp := fiber.Map{
+			"survey_id":           survey.ID,
+			"responses_started":   started,
+			"responses_submitted": submitted,
+			"completion_rate":     completion,
+			"questions":           perQuestion,
+		}
+		if excluded != nil {
+			resp["excluded_respondents"] = len(excluded)
+		}
+		return c.JSON(resp)
+	}
+}
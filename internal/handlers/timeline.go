@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxTimelineBuckets bounds the response size for small buckets over long polls.
+const maxTimelineBuckets = 5000
+
+// TimelineBucket holds the votes cast in [Start, End). Counts follow the
+// order of the timeline's options.
+type TimelineBucket struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Total           int64     `json:"total"`
+	Counts          []int64   `json:"counts"`
+	CumulativeTotal int64     `json:"cumulative_total"`
+	Cumulative      []int64   `json:"cumulative"`
+}
+
+type TimelineOption struct {
+	OptionID   string `json:"option_id"`
+	OptionText string `json:"option_text"`
+	Position   int    `json:"position"`
+}
+
+// parseBucket reads a bucket width such as "30s", "5m", "1h" or "1d". Day
+// buckets are returned as a day count so they follow calendar days across
+// daylight saving changes.
+func parseBucket(s string) (time.Duration, int, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 1 {
+			return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid bucket")
+		}
+		return 0, days, nil
+	}
+	width, err := time.ParseDuration(s)
+	if err != nil || width < time.Second {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid bucket; use e.g. 30s, 5m, 1h or 1d")
+	}
+	return width, 0, nil
+}
+
+// GetPollTimeline buckets a poll's votes over time per option, with both
+// per-bucket and running totals. Buckets are aligned to midnight in the
+// requested time zone and run from the first vote to the last, including
+// empty ones.
+func GetPollTimeline(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findPoll(c, db)
+		if err != nil {
+			return err
+		}
+		if err := guardTallies(c, poll); err != nil {
+			return err
+		}
+		bucket := c.Query("bucket", "5m")
+		width, days, err := parseBucket(bucket)
+		if err != nil {
+			return err
+		}
+		tz := c.Query("tz", "UTC")
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid tz")
+		}
+		next := func(t time.Time) time.Time {
+			if days > 0 {
+				return t.AddDate(0, 0, days)
+			}
+			return t.Add(width)
+		}
+
+		options, err := pollOptions(db, poll.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch poll options")
+		}
+		index := make(map[uuid.UUID]int, len(options))
+		views := make([]TimelineOption, len(options))
+		for i, opt := range options {
+			index[opt.ID] = i
+			views[i] = TimelineOption{OptionID: opt.ID.String(), OptionText: opt.OptionText, Position: opt.Position}
+		}
+
+		var votes []models.Vote
+		err = db.Select("option_id, voted_at").Where("poll_id = ?", poll.ID).Order("voted_at").Find(&votes).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch votes")
+		}
+
+		buckets := []TimelineBucket{}
+		if len(votes) > 0 {
+			first := votes[0].VotedAt.In(loc)
+			start := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+			for !next(start).After(first) {
+				start = next(start)
+			}
+			last := votes[len(votes)-1].VotedAt
+			cumulative := make([]int64, len(options))
+			var cumulativeTotal int64
+			i := 0
+			for !start.After(last) {
+				if len(buckets) == maxTimelineBuckets {
+					return fiber.NewError(fiber.StatusBadRequest, "Bucket too small for this poll; use a wider bucket")
+				}
+				end := next(start)
+				b := TimelineBucket{Start: start, End: end, Counts: make([]int64, len(options))}
+				for ; i < len(votes) && votes[i].VotedAt.Before(end); i++ {
+					if j, ok := index[votes[i].OptionID]; ok {
+						b.Counts[j]++
+						b.Total++
+						cumulative[j]++
+						cumulativeTotal++
+					}
+				}
+				b.Cumulative = append([]int64(nil), cumulative...)
+				b.CumulativeTotal = cumulativeTotal
+				buckets = append(buckets, b)
+				start = end
+			}
+		}
+
+		return c.JSON(fiber.Map{
+			"poll_id":  poll.ID,
+			"bucket":   bucket,
+			"timezone": loc.String(),
+			"options":  views,
+			"buckets":  buckets,
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBucket(t *testing.T) {
+	widths := map[string]time.Duration{
+		"30s":   30 * time.Second,
+		"5m":    5 * time.Minute,
+		"1h30m": 90 * time.Minute,
+	}
+	for bucket, want := range widths {
+		width, days, err := parseBucket(bucket)
+		if err != nil || width != want || days != 0 {
+			t.Errorf("parseBucket(%q) = %v, %d, %v; want %v", bucket, width, days, err, want)
+		}
+	}
+
+	// Day buckets follow the requested time zone's calendar, so they are
+	// counted in days rather than as a fixed width.
+	for bucket, want := range map[string]int{"1d": 1, "7d": 7} {
+		width, days, err := parseBucket(bucket)
+		if err != nil || width != 0 || days != want {
+			t.Errorf("parseBucket(%q) = %v, %d, %v; want %d days", bucket, width, days, err, want)
+		}
+	}
+
+	for _, bucket := range []string{"0d", "-1d", "1.5d", "500ms", "-5m", "5", ""} {
+		if _, _, err := parseBucket(bucket); err == nil {
+			t.Errorf("parseBucket(%q) accepted an invalid bucket", bucket)
+		}
+	}
+}
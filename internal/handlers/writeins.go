@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"gorm.io/gorm"
+)
+
+const maxWriteInLength = 500
+
+type WriteInVariant struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+type WriteInCluster struct {
+	Normalized string           `json:"normalized"`
+	Count      int              `json:"count"`
+	Variants   []WriteInVariant `json:"variants"`
+}
+
+// normalizeWriteIn lowercases text, drops punctuation and collapses
+// whitespace so that "Pizza!" and " pizza" cluster together.
+func normalizeWriteIn(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// ListWriteIns returns the poll's unpromoted write-in answers clustered by
+// normalized text, most frequent first.
+func ListWriteIns(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+
+		var answers []models.WriteInAnswer
+		err = db.Where("poll_id = ? AND promoted_option_id IS NULL", poll.ID).Find(&answers).Error
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch write-ins")
+		}
+
+		return c.JSON(fiber.Map{
+			"poll_id":  poll.ID,
+			"total":    len(answers),
+			"clusters": clusterWriteIns(answers),
+		})
+	}
+}
+
+func clusterWriteIns(answers []models.WriteInAnswer) []WriteInCluster {
+	byKey := make(map[string]map[string]int)
+	for _, a := range answers {
+		if byKey[a.Normalized] == nil {
+			byKey[a.Normalized] = make(map[string]int)
+		}
+		byKey[a.Normalized][a.Text]++
+	}
+
+	clusters := make([]WriteInCluster, 0, len(byKey))
+	for key, variants := range byKey {
+		cluster := WriteInCluster{Normalized: key}
+		for text, n := range variants {
+			cluster.Count += n
+			cluster.Variants = append(cluster.Variants, WriteInVariant{Text: text, Count: n})
+		}
+		sort.Slice(cluster.Variants, func(i, j int) bool {
+			if cluster.Variants[i].Count != cluster.Variants[j].Count {
+				return cluster.Variants[i].Count > cluster.Variants[j].Count
+			}
+			return cluster.Variants[i].Text < cluster.Variants[j].Text
+		})
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		return clusters[i].Normalized < clusters[j].Normalized
+	})
+	return clusters
+}
+
+// PromoteWriteIn turns a cluster of write-in answers into a regular option
+// and moves the matching votes onto it.
+func PromoteWriteIn(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		poll, err := findOwnedPoll(c, db)
+		if err != nil {
+			return err
+		}
+
+		var req struct {
+			Text  string `json:"text"`  // any variant of the cluster to promote
+			Label string `json:"label"` // option text; defaults to the most common variant
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+		}
+		key := normalizeWriteIn(req.Text)
+		if key == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Text required")
+		}
+
+		var option models.PollOption
+		var moved int
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var answers []models.WriteInAnswer
+			err := tx.Where("poll_id = ? AND normalized = ? AND promoted_option_id IS NULL", poll.ID, key).
+				Find(&answers).Error
+			if err != nil {
+				return err
+			}
+			if len(answers) == 0 {
+				return fiber.NewError(fiber.StatusNotFound, "No write-ins match this text")
+			}
+
+			label := strings.TrimSpace(req.Label)
+			if label == "" {
+				label = clusterWriteIns(answers)[0].Variants[0].Text
+			}
+			position, err := nextOptionPosition(tx, poll.ID)
+			if err != nil {
+				return err
+			}
+			option = models.PollOption{
+				ID:         uuid.New(),
+				PollID:     poll.ID,
+				OptionText: label,
+				Position:   position,
+			}
+			if err := tx.Create(&option).Error; err != nil {
+				return err
+			}
+
+			answerIDs := make([]uuid.UUID, len(answers))
+			voteIDs := make([]uuid.UUID, len(answers))
+			for i, a := range answers {
+				answerIDs[i] = a.ID
+				voteIDs[i] = a.VoteID
+			}
+			if err := tx.Model(&models.Vote{}).Where("id IN ?", voteIDs).
+				Update("option_id", option.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.WriteInAnswer{}).Where("id IN ?", answerIDs).
+				Update("promoted_option_id", option.ID).Error; err != nil {
+				return err
+			}
+			moved = len(answers)
+			return nil
+		})
+		if err != nil {
+			if fe, ok := err.(*fiber.Error); ok {
+				return fe
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to promote write-in")
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"option_id":   option.ID,
+			"option_text": option.OptionText,
+			"votes_moved": moved,
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/stats"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// HandleConjointFitTask estimates average marginal component effects for a
+// conjoint study. Each answered task contributes two rows, one per profile,
+// with outcome 1 for the chosen profile. The linear probability model has an
+// intercept and a dummy for every non-baseline level, and standard errors
+// are clustered by respondent. Stored estimates are replaced.
+func HandleConjointFitTask(db *gorm.DB) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var p StudyTaskPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return err
+		}
+		studyID, err := uuid.Parse(p.StudyID)
+		if err != nil {
+			return err
+		}
+		log.Printf("[CONJOINT] Estimating AMCEs for study %s", studyID)
+
+		var attributes []models.ConjointAttribute
+		if err := db.WithContext(ctx).Where("study_id = ?", studyID).Order("position").Find(&attributes).Error; err != nil {
+			return err
+		}
+		// Column of each non-baseline level in the design matrix; column 0
+		// is the intercept.
+		column := map[string]int{}
+		var levels []models.ConjointLevel
+		var levelAttr []uuid.UUID
+		k := 1
+		for _, attr := range attributes {
+			var attrLevels []models.ConjointLevel
+			if err := db.WithContext(ctx).Where("attribute_id = ?", attr.ID).Order("position").Find(&attrLevels).Error; err != nil {
+				return err
+			}
+			for i, level := range attrLevels {
+				if i > 0 {
+					column[level.ID.String()] = k
+					k++
+				}
+				levels = append(levels, level)
+				levelAttr = append(levelAttr, attr.ID)
+			}
+		}
+
+		var tasks []models.ConjointTask
+		if err := db.WithContext(ctx).Where("study_id = ? AND chosen IS NOT NULL", studyID).Find(&tasks).Error; err != nil {
+			return err
+		}
+		respondents := map[uuid.UUID]int{}
+		var x [][]float64
+		var y []float64
+		var clusters []int
+		for _, task := range tasks {
+			if _, ok := respondents[task.UserID]; !ok {
+				respondents[task.UserID] = len(respondents)
+			}
+			for i, profile := range []string{task.ProfileA, task.ProfileB} {
+				row := make([]float64, k)
+				row[0] = 1
+				for _, id := range strings.Split(profile, ",") {
+					if col, ok := column[id]; ok {
+						row[col] = 1
+					}
+				}
+				outcome := 0.0
+				if *task.Chosen == i {
+					outcome = 1
+				}
+				x = append(x, row)
+				y = append(y, outcome)
+				clusters = append(clusters, respondents[task.UserID])
+			}
+		}
+
+		fit, err := stats.ClusteredOLS(x, y, clusters)
+		if err == stats.ErrInsufficientData || err == stats.ErrSingular {
+			log.Printf("[CONJOINT] Not enough choices to estimate study %s: %v", studyID, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		z := stats.NormalQuantile(0.975)
+		now := time.Now()
+		estimates := make([]models.ConjointEstimate, len(levels))
+		for i, level := range levels {
+			e := models.ConjointEstimate{
+				ID:           uuid.New(),
+				StudyID:      studyID,
+				AttributeID:  levelAttr[i],
+				LevelID:      level.ID,
+				Observations: fit.N,
+				Respondents:  fit.Clusters,
+				ComputedAt:   now,
+			}
+			if col, ok := column[level.ID.String()]; ok {
+				e.Estimate = fit.Coef[col]
+				e.StdErr = fit.StdErr[col]
+				e.Lower = e.Estimate - z*e.StdErr
+				e.Upper = e.Estimate + z*e.StdErr
+			} else {
+				e.Baseline = true
+			}
+			estimates[i] = e
+		}
+		return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("study_id = ?", studyID).Delete(&models.ConjointEstimate{}).Error; err != nil {
+				return err
+			}
+			return tx.Create(&estimates).Error
+		})
+	}
+}
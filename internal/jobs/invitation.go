@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gopro/internal/mail"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/sms"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// HandleInvitationTask sends an invitation's personal link. First sends
+// happen once; reminders are dropped if the invitee has voted since they
+// were scheduled.
+func HandleInvitationTask(db *gorm.DB) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var p InvitationTaskPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return err
+		}
+		invitationID, err := uuid.Parse(p.InvitationID)
+		if err != nil {
+			return err
+		}
+
+		var inv models.Invitation
+		if err := db.WithContext(ctx).Where("id = ?", invitationID).First(&inv).Error; err != nil {
+			return err
+		}
+		if inv.VotedAt != nil || (!p.Reminder && inv.SentAt != nil) {
+			return nil
+		}
+		var poll models.Poll
+		if err := db.WithContext(ctx).Where("id = ?", inv.PollID).First(&poll).Error; err != nil {
+			return err
+		}
+		if poll.ClosedAt != nil {
+			return nil
+		}
+
+		subject := "You're invited: " + poll.Title
+		body := fmt.Sprintf("You're invited to answer %q. Your personal link: %s", poll.Title, inv.Link)
+		if p.Reminder {
+			subject = "Reminder: " + poll.Title
+			body = fmt.Sprintf("A reminder to answer %q if you haven't had the chance. Your personal link: %s", poll.Title, inv.Link)
+		}
+		if inv.Channel == "email" {
+			log.Printf("[EMAIL] Sending invitation %s to %s", inv.ID, inv.Identifier)
+			err = mail.Send(inv.Identifier, subject, body)
+		} else {
+			log.Printf("[SMS] Sending invitation %s to %s", inv.ID, inv.Identifier)
+			err = sms.Send(inv.Identifier, body)
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if p.Reminder {
+			return db.WithContext(ctx).Model(&inv).Updates(map[string]interface{}{
+				"reminders":        gorm.Expr("reminders + 1"),
+				"last_reminded_at": now,
+			}).Error
+		}
+		return db.WithContext(ctx).Model(&inv).Update("sent_at", now).Error
+	}
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/gopro/internal/stats"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// HandlePairwiseFitTask fits a Bradley-Terry model to every comparison
+// recorded for a pairwise poll and replaces its stored scores.
+func HandlePairwiseFitTask(db *gorm.DB) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var p PollTaskPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return err
+		}
+		pollID, err := uuid.Parse(p.PollID)
+		if err != nil {
+			return err
+		}
+		log.Printf("[PAIRWISE] Fitting ranking for poll %s", pollID)
+
+		var options []models.PollOption
+		if err := db.WithContext(ctx).Where("poll_id = ?", pollID).Find(&options).Error; err != nil {
+			return err
+		}
+		if len(options) < 2 {
+			return nil
+		}
+		index := make(map[uuid.UUID]int, len(options))
+		for i, opt := range options {
+			index[opt.ID] = i
+		}
+
+		var rows []struct {
+			WinnerID uuid.UUID
+			LoserID  uuid.UUID
+			Count    int
+		}
+		err = db.WithContext(ctx).Model(&models.PairwiseComparison{}).
+			Select("winner_id, loser_id, count(*) as count").
+			Where("poll_id = ?", pollID).
+			Group("winner_id, loser_id").
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		wins := make([][]float64, len(options))
+		for i := range wins {
+			wins[i] = make([]float64, len(options))
+		}
+		comparisons := make([]int, len(options))
+		for _, r := range rows {
+			w, okW := index[r.WinnerID]
+			l, okL := index[r.LoserID]
+			if !okW || !okL {
+				continue
+			}
+			wins[w][l] += float64(r.Count)
+			comparisons[w] += r.Count
+			comparisons[l] += r.Count
+		}
+
+		fit, err := stats.BradleyTerry(wins)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		scores := make([]models.PairwiseScore, len(options))
+		for i, opt := range options {
+			scores[i] = models.PairwiseScore{
+				PollID:      pollID,
+				OptionID:    opt.ID,
+				Score:       fit.Scores[i],
+				StdErr:      fit.StdErr[i],
+				Lower:       fit.Lower[i],
+				Upper:       fit.Upper[i],
+				Comparisons: comparisons[i],
+				ComputedAt:  now,
+			}
+		}
+		return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("poll_id = ?", pollID).Delete(&models.PairwiseScore{}).Error; err != nil {
+				return err
+			}
+			return tx.Create(&scores).Error
+		})
+	}
+}
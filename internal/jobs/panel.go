@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// HandleWaveInvitationTask queues a message to every panel member linking
+// to the wave's poll. It runs when the wave opens and does nothing if the
+// wave was already invited, so retries do not message members twice.
+func HandleWaveInvitationTask(db *gorm.DB, queue *asynq.Client) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var p WaveTaskPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return err
+		}
+		waveID, err := uuid.Parse(p.WaveID)
+		if err != nil {
+			return err
+		}
+
+		var wave models.PanelWave
+		if err := db.WithContext(ctx).Where("id = ?", waveID).First(&wave).Error; err != nil {
+			return err
+		}
+		if wave.InvitedAt != nil {
+			return nil
+		}
+		var panel models.Panel
+		if err := db.WithContext(ctx).Where("id = ?", wave.PanelID).First(&panel).Error; err != nil {
+			return err
+		}
+		var poll models.Poll
+		if err := db.WithContext(ctx).Where("id = ?", wave.PollID).First(&poll).Error; err != nil {
+			return err
+		}
+		var users []models.User
+		err = db.WithContext(ctx).
+			Joins("JOIN panel_members ON panel_members.user_id = users.id").
+			Where("panel_members.panel_id = ?", panel.ID).
+			Find(&users).Error
+		if err != nil {
+			return err
+		}
+		log.Printf("[PANEL] Inviting %d members to wave %d of panel %s", len(users), wave.WaveNumber, panel.ID)
+
+		subject := fmt.Sprintf("%s: wave %d is open", panel.Title, wave.WaveNumber)
+		body := fmt.Sprintf("Wave %d of %s is now open. Please answer here: %s", wave.WaveNumber, panel.Title, poll.ShareableLink)
+		for _, u := range users {
+			// One task per member, deduplicated per wave, so a failed send
+			// is retried on its own.
+			_, err := queue.Enqueue(NewMessageTask(u.Identifier, subject, body),
+				asynq.TaskID("wave:"+wave.ID.String()+":"+u.ID.String()))
+			if err != nil && err != asynq.ErrTaskIDConflict {
+				return err
+			}
+		}
+		return db.WithContext(ctx).Model(&wave).Update("invited_at", time.Now()).Error
+	}
+}
@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gopro/internal/models"
+	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecurringPollConfigs feeds the active recurring poll templates to an
+// asynq.PeriodicTaskManager, which re-reads them on every sync so new,
+// paused and edited schedules are picked up without a restart.
+type RecurringPollConfigs struct {
+	DB *gorm.DB
+}
+
+func (p RecurringPollConfigs) GetConfigs() ([]*asynq.PeriodicTaskConfig, error) {
+	var templates []models.RecurringPoll
+	if err := p.DB.Where("active = ?", true).Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	configs := make([]*asynq.PeriodicTaskConfig, len(templates))
+	for i, t := range templates {
+		configs[i] = &asynq.PeriodicTaskConfig{
+			Cronspec: t.Cronspec,
+			Task:     NewRecurringPollTask(t.ID.String()),
+		}
+	}
+	return configs, nil
+}
+
+// HandleRecurringPollTask opens a new instance of a recurring poll and, if
+// the template asks for it, messages its subscribers. An instance is only
+// created once per scheduled period, so retries and duplicate schedulers
+// do not open it twice.
+func HandleRecurringPollTask(db *gorm.DB, queue *asynq.Client) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var p RecurringTaskPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return err
+		}
+		templateID, err := uuid.Parse(p.RecurringPollID)
+		if err != nil {
+			return err
+		}
+
+		var template models.RecurringPoll
+		var poll *models.Poll
+		err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", templateID).
+				First(&template).Error
+			if err != nil {
+				return err
+			}
+			if !template.Active {
+				return nil
+			}
+			schedule, err := cron.ParseStandard(template.Cronspec)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			if template.LastRunAt != nil && schedule.Next(*template.LastRunAt).After(now) {
+				return nil
+			}
+
+			instance := models.Poll{
+				ID:              uuid.New(),
+				WebsiteID:       template.WebsiteID,
+				Title:           template.Title + " (" + now.Format("2006-01-02") + ")",
+				Description:     template.Description,
+				CreatedBy:       template.CreatedBy,
+				CreatedAt:       now,
+				Mode:            "standard",
+				Phase:           "voting",
+				RecurringPollID: &template.ID,
+			}
+			instance.ShareableLink = template.LinkBase + "/poll/" + instance.ID.String()
+			if err := tx.Create(&instance).Error; err != nil {
+				return err
+			}
+			for i, text := range strings.Split(template.Options, ",") {
+				opt := models.PollOption{
+					ID:         uuid.New(),
+					PollID:     instance.ID,
+					OptionText: text,
+					Position:   i,
+				}
+				if err := tx.Create(&opt).Error; err != nil {
+					return err
+				}
+			}
+			poll = &instance
+			return tx.Model(&template).Update("last_run_at", now).Error
+		})
+		if err != nil {
+			return err
+		}
+		if poll == nil {
+			log.Printf("[RECURRING] Skipping %s: inactive or already opened this period", templateID)
+			return nil
+		}
+		log.Printf("[RECURRING] Opened poll %s for recurring poll %s", poll.ID, templateID)
+		if !template.Notify {
+			return nil
+		}
+
+		var users []models.User
+		err = db.WithContext(ctx).
+			Joins("JOIN recurring_subscriptions ON recurring_subscriptions.user_id = users.id").
+			Where("recurring_subscriptions.recurring_poll_id = ?", templateID).
+			Find(&users).Error
+		if err != nil {
+			return err
+		}
+		subject := poll.Title
+		body := fmt.Sprintf("A new round of %q is open: %s", template.Title, poll.ShareableLink)
+		for _, u := range users {
+			_, err := queue.Enqueue(NewMessageTask(u.Identifier, subject, body),
+				asynq.TaskID("recurring:"+poll.ID.String()+":"+u.ID.String()))
+			if err != nil && err != asynq.ErrTaskIDConflict {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"strings"
 
 	"github.com/hibiken/asynq"
 	"github.com/gopro/internal/mail"
@@ -27,3 +28,16 @@ func HandleSMSTask(ctx context.Context, t *asynq.Task) error {
 	log.Printf("[SMS] Sending OTP to %s", p.Identifier)
 	return sms.SendOTP(p.Identifier, p.OTP)
 }
+
+func HandleMessageTask(ctx context.Context, t *asynq.Task) error {
+	var p MessagePayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return err
+	}
+	if strings.Contains(p.Identifier, "@") {
+		log.Printf("[EMAIL] Sending message to %s", p.Identifier)
+		return mail.Send(p.Identifier, p.Subject, p.Body)
+	}
+	log.Printf("[SMS] Sending message to %s", p.Identifier)
+	return sms.Send(p.Identifier, p.Body)
+}
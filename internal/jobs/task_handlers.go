@@ -9,6 +9,14 @@ import (
 const (
 	TypeEmailOTP = "email:send_otp"
 	TypeSMSOTP   = "sms:send_otp"
+
+	TypePairwiseFit = "pairwise:fit"
+	TypeConjointFit = "conjoint:fit"
+
+	TypeMessage        = "message:send"
+	TypeWaveInvitation = "panel:invite_wave"
+	TypeRecurringPoll  = "recurring:instantiate"
+	TypeInvitation     = "invitation:send"
 )
 
 type OTPTaskPayload struct {
@@ -28,6 +36,72 @@ func NewSMSTask(identifier, otp string) *asynq.Task {
 	return asynq.NewTask(TypeSMSOTP, payload)
 }
 
+type PollTaskPayload struct {
+	PollID string `json:"poll_id"`
+}
+
+// NewPairwiseFitTask creates a new Asynq task to fit a Bradley-Terry ranking for a pairwise poll.
+func NewPairwiseFitTask(pollID string) *asynq.Task {
+	payload, _ := json.Marshal(PollTaskPayload{PollID: pollID})
+	return asynq.NewTask(TypePairwiseFit, payload)
+}
+
+type StudyTaskPayload struct {
+	StudyID string `json:"study_id"`
+}
+
+// NewConjointFitTask creates a new Asynq task to estimate AMCEs for a conjoint study.
+func NewConjointFitTask(studyID string) *asynq.Task {
+	payload, _ := json.Marshal(StudyTaskPayload{StudyID: studyID})
+	return asynq.NewTask(TypeConjointFit, payload)
+}
+
+// MessagePayload is a notification to one participant. Identifiers with an
+// "@" are emailed; anything else is treated as a phone number.
+type MessagePayload struct {
+	Identifier string `json:"identifier"`
+	Subject    string `json:"subject"`
+	Body       string `json:"body"`
+}
+
+// NewMessageTask creates a new Asynq task to notify a participant by email or SMS.
+func NewMessageTask(identifier, subject, body string) *asynq.Task {
+	payload, _ := json.Marshal(MessagePayload{Identifier: identifier, Subject: subject, Body: body})
+	return asynq.NewTask(TypeMessage, payload)
+}
+
+type WaveTaskPayload struct {
+	WaveID string `json:"wave_id"`
+}
+
+// NewWaveInvitationTask creates a new Asynq task to invite a panel to a survey wave.
+func NewWaveInvitationTask(waveID string) *asynq.Task {
+	payload, _ := json.Marshal(WaveTaskPayload{WaveID: waveID})
+	return asynq.NewTask(TypeWaveInvitation, payload)
+}
+
+type RecurringTaskPayload struct {
+	RecurringPollID string `json:"recurring_poll_id"`
+}
+
+// NewRecurringPollTask creates a new Asynq task to open the next instance of a recurring poll.
+func NewRecurringPollTask(recurringPollID string) *asynq.Task {
+	payload, _ := json.Marshal(RecurringTaskPayload{RecurringPollID: recurringPollID})
+	return asynq.NewTask(TypeRecurringPoll, payload)
+}
+
+type InvitationTaskPayload struct {
+	InvitationID string `json:"invitation_id"`
+	Reminder     bool   `json:"reminder"`
+}
+
+// NewInvitationTask creates a new Asynq task to send a poll invitation, or a
+// reminder to an invitee who has not voted yet.
+func NewInvitationTask(invitationID string, reminder bool) *asynq.Task {
+	payload, _ := json.Marshal(InvitationTaskPayload{InvitationID: invitationID, Reminder: reminder})
+	return asynq.NewTask(TypeInvitation, payload)
+}
+
 // NewAsynqClient initializes and returns an Asynq client.
 func NewAsynqClient() *asynq.Client {
 	return asynq.NewClient(asynq.RedisClientOpt{Addr: "redis:6379"})
@@ -8,6 +8,11 @@ import (
 
 // SendOTP sends the OTP to the given email address via SMTP.
 func SendOTP(to string, otp string) error {
+	return Send(to, "Your OTP Code", "Your OTP is: "+otp)
+}
+
+// Send sends a plain-text email to the given address via SMTP.
+func Send(to string, subject string, body string) error {
 	from := os.Getenv("SMTP_USER")
 	pass := os.Getenv("SMTP_PASS")
 	host := os.Getenv("SMTP_HOST")
@@ -16,9 +21,9 @@ func SendOTP(to string, otp string) error {
 	auth := smtp.PlainAuth("", from, pass, host)
 
 	msg := []byte("To: " + to + "\r\n" +
-		"Subject: Your OTP Code\r\n" +
+		"Subject: " + subject + "\r\n" +
 		"\r\n" +
-		"Your OTP is: " + otp + "\r\n")
+		body + "\r\n")
 
 	addr := fmt.Sprintf("%s:%s", host, port)
 	return smtp.SendMail(addr, auth, from, []string{to}, msg)
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BranchRule controls the flow between survey questions based on the
+// answer given to an earlier (source) question.
+//
+// With Action "skip_to" a matching answer jumps straight to TargetPollID.
+// With Action "show_if" TargetPollID is only shown when the answer matches.
+// Operator "equals" matches OptionID; "score_gte" and "score_lte" compare
+// the numeric value of the chosen option's text against Threshold.
+type BranchRule struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	SurveyID     uuid.UUID  `json:"survey_id"`
+	SourcePollID uuid.UUID  `json:"source_poll_id"`
+	Operator     string     `json:"operator"`
+	OptionID     *uuid.UUID `gorm:"type:uuid" json:"option_id,omitempty"`
+	Threshold    *float64   `json:"threshold,omitempty"`
+	Action       string     `json:"action"`
+	TargetPollID uuid.UUID  `json:"target_poll_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConjointStudy asks respondents to choose between pairs of profiles whose
+// attribute levels are randomized.
+type ConjointStudy struct {
+	ID                 uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Title              string
+	Description        string
+	CreatedBy          string
+	CreatedAt          time.Time
+	Design             string // "full" or "fractional"
+	FractionSize       int    // number of distinct profiles in a fractional design
+	Fraction           string // comma-separated full-factorial indices of those profiles
+	TasksPerRespondent int
+}
+
+type ConjointAttribute struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	StudyID  uuid.UUID
+	Name     string
+	Position int
+}
+
+// ConjointLevel is one value an attribute can take. The first level of each
+// attribute is the baseline for effect estimates.
+type ConjointLevel struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	AttributeID uuid.UUID
+	Label       string
+	Position    int
+}
+
+// ConjointTask is one choice shown to a respondent. Profiles are stored as
+// comma-separated level ids in attribute order.
+type ConjointTask struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	StudyID    uuid.UUID
+	UserID     uuid.UUID
+	TaskNumber int
+	ProfileA   string
+	ProfileB   string
+	Chosen     *int // 0 for profile A, 1 for profile B; nil until answered
+	CreatedAt  time.Time
+	AnsweredAt *time.Time
+}
+
+// ConjointEstimate is the average marginal component effect of a level
+// against its attribute's baseline, fitted by the worker.
+type ConjointEstimate struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	StudyID      uuid.UUID
+	AttributeID  uuid.UUID
+	LevelID      uuid.UUID
+	Baseline     bool
+	Estimate     float64
+	StdErr       float64
+	Lower        float64
+	Upper        float64
+	Observations int
+	Respondents  int
+	ComputedAt   time.Time
+}
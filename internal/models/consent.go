@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConsentText is one version of the consent statement attached to a poll.
+// The highest version is current.
+type ConsentText struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID    uuid.UUID
+	Version   int
+	Text      string
+	CreatedAt time.Time
+}
+
+// Consent records a participant accepting a consent text version.
+type Consent struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID        uuid.UUID
+	UserID        uuid.UUID
+	ConsentTextID uuid.UUID
+	Version       int
+	AcceptedAt    time.Time
+}
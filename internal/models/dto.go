@@ -36,12 +36,39 @@ type Poll struct {
     CreatedAt   time.Time
     Options     []PollOption `gorm:"foreignKey:PollID"`
     ShareableLink string `gorm:"type:varchar(255);unique"`
+    Mode        string `gorm:"default:standard"` // "standard", "pairwise" or "randomized_response"
+    Phase       string `gorm:"default:voting"`   // "submission" or "voting"
+    SurveyID    *uuid.UUID `gorm:"type:uuid"`   // set when the poll is a survey question
+    // InfluenceConditions lists the result-visibility conditions participants
+    // are randomized into ("visible", "seeded", "blind"); empty disables it.
+    InfluenceConditions string
+    ShuffleOptions      bool // show options in a per-respondent random order
+    MaxResponseSeconds  int  // votes later than this after first fetch are rejected; 0 disables
+    // Randomized response design: respondents answer truthfully with
+    // TruthProbability, otherwise give "yes" with ForcedYesProbability.
+    TruthProbability     float64
+    ForcedYesProbability float64
+    InstructionKey       string `json:"-"` // per-poll secret keying the instruction draw
+    // Differential privacy: non-owners only see noisy releases, each costing
+    // PrivacyEpsilon out of PrivacyBudget. A zero budget disables it.
+    PrivacyBudget    float64
+    PrivacyEpsilon   float64
+    PrivacyDelta     float64
+    PrivacyMechanism string // "laplace" or "gaussian"
+    MaxVotes         int        // close automatically after this many votes; 0 disables
+    ClosedAt         *time.Time // no votes are accepted once set
+    CorrectOptionID  *uuid.UUID `gorm:"type:uuid"` // set on attention-check polls
+    RecurringPollID  *uuid.UUID `gorm:"type:uuid"` // set on instances of a recurring poll
 }
 
 type PollOption struct {
     ID      uuid.UUID `gorm:"type:uuid;primaryKey"`
     PollID  uuid.UUID
     OptionText string
+    SubmittedBy *uuid.UUID `gorm:"type:uuid"` // set for participant-submitted options
+    WriteIn    bool // voters choosing this option may add free text
+    Position   int  // display order within the poll, starting at 0
+    SeedCount  int  // baseline added to the real tally in the "seeded" condition
 }
 
 type User struct {
@@ -55,4 +82,12 @@ type Vote struct {
     OptionID  uuid.UUID
     UserID    uuid.UUID
     VotedAt   time.Time
+    Condition string // result-visibility condition the voter was shown, if any
+    DisplayedPosition *int // where the chosen option appeared for the voter
+    // Paradata: first fetch of the poll, time to vote and client-reported
+    // selection changes. Nil for votes cast without it.
+    FirstFetchedAt   *time.Time
+    LatencyMs        *int64
+    SelectionChanges *int
+    ReferredBy       *uuid.UUID `gorm:"type:uuid"` // user whose share link the voter arrived through
 }
\ No newline at end of file
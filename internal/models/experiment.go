@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Experiment randomizes participants across arms, each showing a different
+// variant poll.
+//
+// Randomization "hash" assigns each participant by hashing their ID;
+// "block" draws from permuted blocks of BlockSize kept per stratum, so arms
+// stay balanced in small samples. StratifyBy is a comma-separated list of
+// participant attributes ("country", "age_group") that define the strata.
+type Experiment struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Title         string
+	Description   string
+	CreatedBy     string
+	CreatedAt     time.Time
+	Randomization string `gorm:"default:hash"`
+	BlockSize     int
+	StratifyBy    string
+	TargetPerArm  int // planned sample per arm; 0 if not set
+}
+
+// ExperimentArm links a variant poll to an experiment with an assignment weight.
+type ExperimentArm struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ExperimentID uuid.UUID
+	PollID       uuid.UUID
+	Name         string
+	Weight       float64
+	Position     int
+}
+
+// ExperimentAssignment records which arm a participant was assigned to.
+type ExperimentAssignment struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ExperimentID uuid.UUID
+	ArmID        uuid.UUID
+	UserID       uuid.UUID
+	Stratum      string
+	AssignedAt   time.Time
+}
+
+// ExperimentBlock is the permuted block currently being handed out for one
+// stratum of a block-randomized experiment. Sequence lists arm IDs in
+// assignment order; Next is the index of the next unused slot.
+type ExperimentBlock struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ExperimentID uuid.UUID
+	Stratum      string
+	BlockNumber  int
+	Sequence     string
+	Next         int
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ResultExposure records which result-visibility condition a participant
+// was assigned for a poll and when they were first shown it.
+type ResultExposure struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID    uuid.UUID
+	UserID    uuid.UUID
+	Condition string // "visible", "seeded" or "blind"
+	ShownAt   time.Time
+}
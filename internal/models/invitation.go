@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation asks one email address or phone number to answer a poll. The
+// token in its link records when it was opened; a vote by the user with the
+// same identifier marks it voted.
+type Invitation struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID         uuid.UUID
+	Identifier     string
+	Channel        string // "email" or "sms"
+	Token          string
+	Link           string
+	CreatedAt      time.Time
+	SentAt         *time.Time
+	OpenedAt       *time.Time
+	VotedAt        *time.Time
+	Reminders      int
+	LastRemindedAt *time.Time
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PairwiseComparison records one head-to-head choice in a pairwise poll.
+type PairwiseComparison struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID     uuid.UUID
+	WinnerID   uuid.UUID
+	LoserID    uuid.UUID
+	UserID     uuid.UUID
+	ComparedAt time.Time
+}
+
+// PairwiseScore is an option's Bradley-Terry log-strength with a 95%
+// confidence interval, as computed by the worker.
+type PairwiseScore struct {
+	PollID      uuid.UUID `gorm:"type:uuid;primaryKey"`
+	OptionID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Score       float64
+	StdErr      float64
+	Lower       float64
+	Upper       float64
+	Comparisons int
+	ComputedAt  time.Time
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Panel follows a fixed group of participants across repeated waves of the
+// same poll.
+type Panel struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Title        string
+	CreatedBy    string
+	CreatedAt    time.Time
+	SourcePollID uuid.UUID // template every wave's poll is copied from
+}
+
+type PanelMember struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PanelID  uuid.UUID
+	UserID   uuid.UUID
+	JoinedAt time.Time
+}
+
+// PanelWave is one round of the panel, answered on its own copy of the
+// source poll so responses can be compared between waves per user.
+type PanelWave struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PanelID    uuid.UUID
+	PollID     uuid.UUID
+	WaveNumber int
+	OpensAt    time.Time
+	InvitedAt  *time.Time // set once the worker has queued invitations
+}
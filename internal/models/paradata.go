@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PollView records when a participant first fetched a poll, the start of
+// their response time, and whose share link brought them there.
+type PollView struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID         uuid.UUID
+	UserID         uuid.UUID
+	FirstFetchedAt time.Time
+	ReferredBy     *uuid.UUID `gorm:"type:uuid"`
+}
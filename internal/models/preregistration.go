@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Preregistration fixes the hypotheses and analysis plan of a poll or
+// experiment before data collection. Commitment is the hex SHA-256 of
+// Document, the exact canonical JSON that was registered.
+type Preregistration struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	PollID       *uuid.UUID `gorm:"type:uuid"`
+	ExperimentID *uuid.UUID `gorm:"type:uuid"`
+	CreatedBy    string
+	Document     string
+	Commitment   string
+	CommittedAt  time.Time
+	LockedAt     *time.Time // set when the first vote arrives; no changes after
+}
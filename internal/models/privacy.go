@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PrivacyRelease is one noisy publication of a poll's counts and the
+// privacy budget it consumed. Releases are reused until new votes arrive.
+type PrivacyRelease struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID     uuid.UUID
+	Mechanism  string // "laplace" or "gaussian"
+	Epsilon    float64
+	Delta      float64
+	TotalVotes int64  // true vote count the release was computed from
+	Counts     string // noisy counts as "option_id=count" pairs, comma-separated
+	ReleasedAt time.Time
+}
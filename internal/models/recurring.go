@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecurringPoll is a template the worker turns into a new poll each time
+// its cron schedule fires.
+type RecurringPoll struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WebsiteID   string
+	Title       string
+	Description string
+	Options     string // comma-separated option texts
+	Cronspec    string // standard 5-field cron, optionally prefixed with CRON_TZ=
+	LinkBase    string // base URL for the shareable links of instances
+	Notify      bool   // message subscribers when an instance opens
+	Active      bool
+	CreatedBy   string
+	CreatedAt   time.Time
+	LastRunAt   *time.Time
+}
+
+// RecurringSubscription asks for a message whenever a new instance opens.
+type RecurringSubscription struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey"`
+	RecurringPollID uuid.UUID
+	UserID          uuid.UUID
+	SubscribedAt    time.Time
+}
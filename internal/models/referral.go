@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShareToken identifies a participant's personal share link for a poll.
+type ShareToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID    uuid.UUID
+	UserID    uuid.UUID
+	Token     string
+	CreatedAt time.Time
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Screener is one eligibility rule of a poll, checked in Position order.
+//
+// Kind "question" asks Question with Options and accepts the answers in
+// Accept; "country" accepts participants whose phone number belongs to one
+// of Countries; "completed_poll" requires a vote on RequiredPollID. Lists
+// are comma-separated.
+type Screener struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID         uuid.UUID
+	Kind           string
+	Question       string
+	Options        string
+	Accept         string
+	Countries      string
+	RequiredPollID *uuid.UUID `gorm:"type:uuid"`
+	Position       int
+}
+
+// ScreeningResult is a participant's outcome on a poll's screeners, kept
+// apart from votes so screen-out rates can be reported.
+type ScreeningResult struct {
+	ID               uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID           uuid.UUID
+	UserID           uuid.UUID
+	Eligible         bool
+	FailedScreenerID *uuid.UUID `gorm:"type:uuid"`
+	Answers          string     // JSON object of screener id to answer
+	ScreenedAt       time.Time
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OptionSubmission is an option proposed by a participant during a poll's
+// submission phase. Once approved it is copied into a PollOption.
+type OptionSubmission struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	PollID      uuid.UUID  `json:"poll_id"`
+	SubmittedBy uuid.UUID  `json:"submitted_by"`
+	Text        string     `json:"text"`
+	Status      string     `json:"status"` // "pending", "approved" or "rejected"
+	OptionID    *uuid.UUID `gorm:"type:uuid" json:"option_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Survey groups ordered questions; each question is a Poll with options.
+type Survey struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Title       string
+	Description string
+	CreatedBy   string
+	CreatedAt   time.Time
+}
+
+// SurveyQuestion places a question poll at a position within a survey.
+type SurveyQuestion struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	SurveyID uuid.UUID
+	PollID   uuid.UUID
+	Position int
+}
+
+// SurveyResponse is one participant's session across a survey's questions.
+// Answers are drafts until SubmittedAt is set, at which point they are
+// written out as Votes.
+type SurveyResponse struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	SurveyID    uuid.UUID
+	UserID      uuid.UUID
+	StartedAt   time.Time
+	UpdatedAt   time.Time
+	SubmittedAt *time.Time
+}
+
+// SurveyAnswer is the option currently chosen for one question of a response.
+type SurveyAnswer struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ResponseID uuid.UUID
+	PollID     uuid.UUID
+	OptionID   uuid.UUID
+	AnsweredAt time.Time
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WriteInAnswer is the free text a voter supplied with a write-in option.
+// Normalized groups equivalent answers for clustering and promotion.
+type WriteInAnswer struct {
+	ID               uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID           uuid.UUID
+	OptionID         uuid.UUID
+	VoteID           uuid.UUID
+	UserID           uuid.UUID
+	Text             string
+	Normalized       string
+	PromotedOptionID *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt        time.Time
+}
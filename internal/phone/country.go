@@ -0,0 +1,38 @@
+package phone
+
+import "strings"
+
+// callingCodes maps international calling codes to ISO 3166 country codes.
+// Codes shared by several countries map to the largest one (+1 to US, +7 to RU).
+var callingCodes = map[string]string{
+	"1": "US", "7": "RU", "20": "EG", "27": "ZA", "30": "GR", "31": "NL",
+	"32": "BE", "33": "FR", "34": "ES", "36": "HU", "39": "IT", "40": "RO",
+	"41": "CH", "43": "AT", "44": "GB", "45": "DK", "46": "SE", "47": "NO",
+	"48": "PL", "49": "DE", "51": "PE", "52": "MX", "53": "CU", "54": "AR",
+	"55": "BR", "56": "CL", "57": "CO", "58": "VE", "60": "MY", "61": "AU",
+	"62": "ID", "63": "PH", "64": "NZ", "65": "SG", "66": "TH", "81": "JP",
+	"82": "KR", "84": "VN", "86": "CN", "90": "TR", "91": "IN", "92": "PK",
+	"93": "AF", "94": "LK", "95": "MM", "98": "IR", "212": "MA", "213": "DZ",
+	"216": "TN", "234": "NG", "233": "GH", "254": "KE", "255": "TZ", "256": "UG",
+	"251": "ET", "351": "PT", "353": "IE", "358": "FI", "380": "UA", "420": "CZ",
+	"852": "HK", "880": "BD", "886": "TW", "966": "SA", "971": "AE", "972": "IL",
+	"977": "NP",
+}
+
+// CountryCode returns the ISO country code for an E.164 phone number such as
+// "+919812345678", or "" if the identifier is not a recognised phone number.
+func CountryCode(identifier string) string {
+	if !strings.HasPrefix(identifier, "+") {
+		return ""
+	}
+	digits := identifier[1:]
+	for n := 3; n >= 1; n-- {
+		if len(digits) < n {
+			continue
+		}
+		if country, ok := callingCodes[digits[:n]]; ok {
+			return country
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package privacy
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	mrand "math/rand/v2"
+)
+
+// Mechanisms supported for releasing counts.
+const (
+	Laplace  = "laplace"
+	Gaussian = "gaussian"
+)
+
+// ErrInvalidParameters is returned for an epsilon or delta the mechanism
+// cannot be calibrated with.
+var ErrInvalidParameters = errors.New("privacy: invalid parameters")
+
+// Validate checks that a mechanism can be calibrated for one release.
+// The Gaussian mechanism's classic calibration only holds for epsilon < 1.
+func Validate(mechanism string, epsilon, delta float64) error {
+	if epsilon <= 0 {
+		return ErrInvalidParameters
+	}
+	switch mechanism {
+	case Laplace:
+		return nil
+	case Gaussian:
+		if epsilon >= 1 || delta <= 0 || delta >= 1 {
+			return ErrInvalidParameters
+		}
+		return nil
+	}
+	return ErrInvalidParameters
+}
+
+// NoisyCounts adds noise calibrated to a histogram where each participant
+// contributes one vote, so adding or removing a participant changes a
+// single count by one (L1 and L2 sensitivity 1). Results are rounded and
+// clamped at zero, which is post-processing and costs no privacy.
+func NoisyCounts(counts []int64, mechanism string, epsilon, delta float64) ([]int64, error) {
+	if err := Validate(mechanism, epsilon, delta); err != nil {
+		return nil, err
+	}
+	r, err := newRand()
+	if err != nil {
+		return nil, err
+	}
+	return noisyCounts(r, counts, mechanism, epsilon, delta), nil
+}
+
+func noisyCounts(r *mrand.Rand, counts []int64, mechanism string, epsilon, delta float64) []int64 {
+	noisy := make([]int64, len(counts))
+	for i, c := range counts {
+		var noise float64
+		switch mechanism {
+		case Laplace:
+			noise = laplace(r, 1/epsilon)
+		case Gaussian:
+			noise = r.NormFloat64() * math.Sqrt(2*math.Log(1.25/delta)) / epsilon
+		}
+		noisy[i] = int64(math.Max(0, math.Round(float64(c)+noise)))
+	}
+	return noisy
+}
+
+// laplace draws from Laplace(0, scale) by inverting the CDF.
+func laplace(r *mrand.Rand, scale float64) float64 {
+	u := r.Float64() - 0.5
+	for u == -0.5 {
+		u = r.Float64() - 0.5
+	}
+	sign := 1.0
+	if u < 0 {
+		sign = -1
+	}
+	return -scale * sign * math.Log(1-2*math.Abs(u))
+}
+
+// newRand returns a generator seeded from the operating system's
+// cryptographic source, so noise cannot be predicted or replayed.
+func newRand() (*mrand.Rand, error) {
+	var seed [32]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		return nil, err
+	}
+	return mrand.New(mrand.NewChaCha8(seed)), nil
+}
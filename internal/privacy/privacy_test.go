@@ -0,0 +1,91 @@
+package privacy
+
+import (
+	"errors"
+	"math"
+	mrand "math/rand/v2"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []struct {
+		mechanism      string
+		epsilon, delta float64
+	}{
+		{Laplace, 0.5, 0},
+		{Laplace, 5, 0},
+		{Gaussian, 0.5, 1e-5},
+	}
+	for _, v := range valid {
+		if err := Validate(v.mechanism, v.epsilon, v.delta); err != nil {
+			t.Errorf("Validate(%s, %v, %v) = %v, want nil", v.mechanism, v.epsilon, v.delta, err)
+		}
+	}
+
+	invalid := []struct {
+		mechanism      string
+		epsilon, delta float64
+	}{
+		{Laplace, 0, 0},
+		{Gaussian, 1, 1e-5}, // calibration needs epsilon < 1
+		{Gaussian, 0.5, 0},
+		{Gaussian, 0.5, 1},
+		{"exponential", 0.5, 0},
+	}
+	for _, v := range invalid {
+		if err := Validate(v.mechanism, v.epsilon, v.delta); !errors.Is(err, ErrInvalidParameters) {
+			t.Errorf("Validate(%s, %v, %v) = %v, want %v", v.mechanism, v.epsilon, v.delta, err, ErrInvalidParameters)
+		}
+	}
+}
+
+// noiseSD returns the standard deviation of noisyCounts' noise around a
+// count large enough never to be clamped.
+func noiseSD(mechanism string, epsilon, delta float64) float64 {
+	const n = 2000
+	counts := make([]int64, n)
+	for i := range counts {
+		counts[i] = 1000
+	}
+	noisy := noisyCounts(mrand.New(mrand.NewPCG(1, 2)), counts, mechanism, epsilon, delta)
+	var sum, sq float64
+	for _, c := range noisy {
+		d := float64(c - 1000)
+		sum += d
+		sq += d * d
+	}
+	return math.Sqrt(sq/n - (sum/n)*(sum/n))
+}
+
+func TestLaplaceNoiseScale(t *testing.T) {
+	// Laplace(0, 1/epsilon) has standard deviation sqrt(2)/epsilon.
+	for _, epsilon := range []float64{1, 0.1} {
+		want := math.Sqrt2 / epsilon
+		if sd := noiseSD(Laplace, epsilon, 0); math.Abs(sd-want) > 0.1*want {
+			t.Errorf("epsilon %v: noise sd = %v, want about %v", epsilon, sd, want)
+		}
+	}
+}
+
+func TestGaussianNoiseScale(t *testing.T) {
+	epsilon, delta := 0.5, 1e-5
+	want := math.Sqrt(2*math.Log(1.25/delta)) / epsilon
+	if sd := noiseSD(Gaussian, epsilon, delta); math.Abs(sd-want) > 0.1*want {
+		t.Errorf("noise sd = %v, want about %v", sd, want)
+	}
+}
+
+func TestNoisyCountsClampsAtZero(t *testing.T) {
+	noisy, err := NoisyCounts(make([]int64, 100), Laplace, 0.1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range noisy {
+		if c < 0 {
+			t.Fatalf("negative count %d", c)
+		}
+	}
+	if _, err := NoisyCounts([]int64{1}, Gaussian, 2, 1e-5); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidParameters)
+	}
+}
@@ -0,0 +1,40 @@
+package randomize
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+)
+
+// Unit maps the given parts to a deterministic number in [0, 1). The same
+// parts always produce the same value, so it can be used to assign a
+// participant to a condition without storing any random state.
+func Unit(parts ...string) float64 {
+	sum := sha256.Sum256([]byte(strings.Join(parts, ":")))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+// Weighted returns the index of the bucket that u in [0, 1) falls into when
+// the unit interval is split proportionally to weights.
+func Weighted(u float64, weights []float64) int {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	target := u * total
+	var cum float64
+	for i, w := range weights {
+		cum += w
+		if target < cum {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+// Seed derives a deterministic 64-bit seed from the given parts, for
+// reproducible simulations over the same data.
+func Seed(parts ...string) uint64 {
+	sum := sha256.Sum256([]byte(strings.Join(parts, ":")))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,71 @@
+package randomize
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestUnitIsDeterministic(t *testing.T) {
+	if Unit("poll", "user") != Unit("poll", "user") {
+		t.Fatal("same parts gave different draws")
+	}
+	for _, parts := range [][]string{
+		{"poll", "user-2"},
+		{"user", "poll"},
+		{"poll", "user", "order"},
+	} {
+		if Unit(parts...) == Unit("poll", "user") {
+			t.Errorf("Unit(%q) collides with Unit(poll, user)", parts)
+		}
+	}
+}
+
+func TestUnitIsUniform(t *testing.T) {
+	// Inputs are fixed, so the counts are too; the bound only documents
+	// what a uniform draw should look like.
+	const n = 5000
+	var buckets [10]int
+	for i := 0; i < n; i++ {
+		u := Unit("experiment", strconv.Itoa(i))
+		if u < 0 || u >= 1 {
+			t.Fatalf("Unit = %v, want a value in [0, 1)", u)
+		}
+		buckets[int(u*10)]++
+	}
+	for i, count := range buckets {
+		if count < 400 || count > 600 {
+			t.Errorf("bucket %d has %d of %d draws", i, count, n)
+		}
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	tests := []struct {
+		u       float64
+		weights []float64
+		want    int
+	}{
+		{0, []float64{1, 1}, 0},
+		{0.49, []float64{1, 1}, 0},
+		{0.5, []float64{1, 1}, 1},
+		{0.2, []float64{1, 3}, 0},
+		{0.3, []float64{1, 3}, 1},
+		{0.75, []float64{2, 2, 4}, 2}, // weights need not sum to 1
+		{0, []float64{0, 1}, 1},       // zero weights are never chosen
+		{0.999, []float64{1, 1, 1}, 2},
+	}
+	for _, tt := range tests {
+		if got := Weighted(tt.u, tt.weights); got != tt.want {
+			t.Errorf("Weighted(%v, %v) = %d, want %d", tt.u, tt.weights, got, tt.want)
+		}
+	}
+}
+
+func TestSeed(t *testing.T) {
+	if Seed("study", "user") != Seed("study", "user") {
+		t.Error("Seed is not deterministic")
+	}
+	if Seed("study", "user") == Seed("study", "other") {
+		t.Error("different parts gave the same seed")
+	}
+}
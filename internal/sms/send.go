@@ -10,6 +10,11 @@ import (
 
 // SendOTP sends the OTP to the given phone number using Twilio.
 func SendOTP(to string, otp string) error {
+	return Send(to, fmt.Sprintf("Your OTP is: %s", otp))
+}
+
+// Send sends a text message to the given phone number using Twilio.
+func Send(to string, body string) error {
 	client := twilio.NewRestClientWithParams(twilio.ClientParams{
 		Username: os.Getenv("TWILIO_ACCOUNT_SID"),
 		Password: os.Getenv("TWILIO_AUTH_TOKEN"),
@@ -18,7 +23,7 @@ func SendOTP(to string, otp string) error {
 	params := &openapi.CreateMessageParams{}
 	params.SetTo(to)
 	params.SetFrom(os.Getenv("TWILIO_PHONE_NUMBER"))
-	params.SetBody(fmt.Sprintf("Your OTP is: %s", otp))
+	params.SetBody(body)
 
 	_, err := client.Api.CreateMessage(params)
 	return err
@@ -0,0 +1,179 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// BetaCDF returns the regularized incomplete beta function I_x(a, b).
+func BetaCDF(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction for I_x(a, b)
+// using the modified Lentz method.
+func betaContinuedFraction(x, a, b float64) float64 {
+	const tiny = 1e-300
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m < 500; m++ {
+		fm := float64(m)
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-14 {
+			break
+		}
+	}
+	return h
+}
+
+// BetaQuantile returns x such that I_x(a, b) = p, found by bisection.
+func BetaQuantile(p, a, b float64) float64 {
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if BetaCDF(mid, a, b) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// BetaSummary describes a Beta(a, b) posterior with an equal-tailed
+// credible interval.
+type BetaSummary struct {
+	Mean  float64 `json:"mean"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// SummarizeBeta returns the mean and equal-tailed credible interval at the
+// given level (for example 0.95) of a Beta(a, b) distribution.
+func SummarizeBeta(a, b, level float64) BetaSummary {
+	tail := (1 - level) / 2
+	return BetaSummary{
+		Mean:  a / (a + b),
+		Lower: BetaQuantile(tail, a, b),
+		Upper: BetaQuantile(1-tail, a, b),
+	}
+}
+
+// SampleGamma draws from Gamma(shape, 1) using Marsaglia and Tsang's method.
+func SampleGamma(r *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		// Boost to shape+1 and scale back down.
+		return SampleGamma(r, shape+1) * math.Pow(r.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := r.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
+// SampleBeta draws from Beta(a, b).
+func SampleBeta(r *rand.Rand, a, b float64) float64 {
+	x := SampleGamma(r, a)
+	y := SampleGamma(r, b)
+	return x / (x + y)
+}
+
+// DirichletLeaderProbabilities estimates, for each component of a
+// Dirichlet(alpha) distribution, the probability that it is the largest.
+func DirichletLeaderProbabilities(r *rand.Rand, alpha []float64, draws int) []float64 {
+	wins := make([]float64, len(alpha))
+	for n := 0; n < draws; n++ {
+		best, bestVal := 0, -1.0
+		for i, a := range alpha {
+			// Normalizing is unnecessary when only the arg max matters.
+			if v := SampleGamma(r, a); v > bestVal {
+				best, bestVal = i, v
+			}
+		}
+		wins[best]++
+	}
+	for i := range wins {
+		wins[i] /= float64(draws)
+	}
+	return wins
+}
+
+// BetaBestProbabilities estimates, for independent Beta(a[i], b[i])
+// variables, the probability that each is the largest and the probability
+// that each exceeds the first one.
+func BetaBestProbabilities(r *rand.Rand, a, b []float64, draws int) (best, beatsFirst []float64) {
+	best = make([]float64, len(a))
+	beatsFirst = make([]float64, len(a))
+	sample := make([]float64, len(a))
+	for n := 0; n < draws; n++ {
+		top := 0
+		for i := range a {
+			sample[i] = SampleBeta(r, a[i], b[i])
+			if sample[i] > sample[top] {
+				top = i
+			}
+		}
+		best[top]++
+		for i := 1; i < len(a); i++ {
+			if sample[i] > sample[0] {
+				beatsFirst[i]++
+			}
+		}
+	}
+	for i := range best {
+		best[i] /= float64(draws)
+		beatsFirst[i] /= float64(draws)
+	}
+	return best, beatsFirst
+}
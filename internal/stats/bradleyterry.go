@@ -0,0 +1,113 @@
+package stats
+
+import "math"
+
+// btPseudoCount is a small number of virtual wins added in both directions
+// between every pair, so options that never won still get a finite score
+// and the comparison graph is always connected.
+const btPseudoCount = 0.1
+
+// BTResult holds a Bradley-Terry fit for k items. Scores are log-strengths
+// centred on zero; Lower and Upper bound a 95% Wald confidence interval.
+type BTResult struct {
+	Scores []float64
+	StdErr []float64
+	Lower  []float64
+	Upper  []float64
+}
+
+// BradleyTerry fits a Bradley-Terry model where wins[i][j] is the number of
+// times item i was preferred over item j. It uses Hunter's MM algorithm and
+// derives standard errors from the Fisher information under a sum-to-zero
+// constraint.
+func BradleyTerry(wins [][]float64) (BTResult, error) {
+	k := len(wins)
+	w := make([][]float64, k)
+	for i := range wins {
+		w[i] = make([]float64, k)
+		for j := range wins[i] {
+			if i != j {
+				w[i][j] = wins[i][j] + btPseudoCount
+			}
+		}
+	}
+
+	p := make([]float64, k)
+	for i := range p {
+		p[i] = 1
+	}
+	for iter := 0; iter < 1000; iter++ {
+		next := make([]float64, k)
+		var sum float64
+		for i := 0; i < k; i++ {
+			var won, denom float64
+			for j := 0; j < k; j++ {
+				if i == j {
+					continue
+				}
+				won += w[i][j]
+				denom += (w[i][j] + w[j][i]) / (p[i] + p[j])
+			}
+			next[i] = won / denom
+			sum += next[i]
+		}
+		var change float64
+		for i := range next {
+			next[i] *= float64(k) / sum
+			change = math.Max(change, math.Abs(next[i]-p[i]))
+		}
+		p = next
+		if change < 1e-9 {
+			break
+		}
+	}
+
+	res := BTResult{
+		Scores: make([]float64, k),
+		StdErr: make([]float64, k),
+		Lower:  make([]float64, k),
+		Upper:  make([]float64, k),
+	}
+	var mean float64
+	for i := range p {
+		res.Scores[i] = math.Log(p[i])
+		mean += res.Scores[i] / float64(k)
+	}
+	for i := range res.Scores {
+		res.Scores[i] -= mean
+	}
+
+	// The information matrix is a weighted graph Laplacian whose null space
+	// is the all-ones vector, so (I + J/k)^-1 - J/k is its pseudo-inverse.
+	info := make([][]float64, k)
+	for i := range info {
+		info[i] = make([]float64, k)
+	}
+	for i := 0; i < k; i++ {
+		for j := i + 1; j < k; j++ {
+			n := w[i][j] + w[j][i]
+			pij := p[i] / (p[i] + p[j])
+			v := n * pij * (1 - pij)
+			info[i][i] += v
+			info[j][j] += v
+			info[i][j] -= v
+			info[j][i] -= v
+		}
+	}
+	for i := range info {
+		for j := range info[i] {
+			info[i][j] += 1 / float64(k)
+		}
+	}
+	cov, err := Invert(info)
+	if err != nil {
+		return res, err
+	}
+	for i := 0; i < k; i++ {
+		variance := cov[i][i] - 1/float64(k)
+		res.StdErr[i] = math.Sqrt(math.Max(variance, 0))
+		res.Lower[i] = res.Scores[i] - 1.96*res.StdErr[i]
+		res.Upper[i] = res.Scores[i] + 1.96*res.StdErr[i]
+	}
+	return res, nil
+}
@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBradleyTerryTwoItems(t *testing.T) {
+	// With two items the fit has a closed form: the log-odds of winning is
+	// the log of the win ratio after adding the pseudo-counts.
+	w01, w10 := 30+btPseudoCount, 10+btPseudoCount
+	n := w01 + w10
+	p := w01 / n
+	wantScore := math.Log(w01/w10) / 2
+	wantSE := math.Sqrt(1/(n*p*(1-p))) / 2
+
+	res, err := BradleyTerry([][]float64{{0, 30}, {10, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(res.Scores[0], wantScore, 1e-6) || !near(res.Scores[1], -wantScore, 1e-6) {
+		t.Errorf("scores = %v, want ±%v", res.Scores, wantScore)
+	}
+	for i, se := range res.StdErr {
+		if !near(se, wantSE, 1e-6) {
+			t.Errorf("stderr[%d] = %v, want %v", i, se, wantSE)
+		}
+		if res.Lower[i] > res.Scores[i] || res.Upper[i] < res.Scores[i] {
+			t.Errorf("interval [%v, %v] excludes score %v", res.Lower[i], res.Upper[i], res.Scores[i])
+		}
+	}
+}
+
+func TestBradleyTerryTiesScoreZero(t *testing.T) {
+	for _, wins := range [][][]float64{
+		{{0, 0}, {0, 0}},
+		{{0, 5, 5}, {5, 0, 5}, {5, 5, 0}},
+	} {
+		res, err := BradleyTerry(wins)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, s := range res.Scores {
+			if !near(s, 0, 1e-6) {
+				t.Errorf("%v: score[%d] = %v, want 0", wins, i, s)
+			}
+		}
+	}
+}
+
+func TestBradleyTerryOrdersTransitiveWins(t *testing.T) {
+	res, err := BradleyTerry([][]float64{
+		{0, 8, 9},
+		{2, 0, 7},
+		{1, 3, 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(res.Scores[0] > res.Scores[1] && res.Scores[1] > res.Scores[2]) {
+		t.Errorf("scores %v are not ordered by strength", res.Scores)
+	}
+	if sum := res.Scores[0] + res.Scores[1] + res.Scores[2]; !near(sum, 0, 1e-9) {
+		t.Errorf("scores sum to %v, want 0", sum)
+	}
+}
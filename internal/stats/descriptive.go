@@ -0,0 +1,70 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Summary describes the distribution of a sample.
+type Summary struct {
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	SD     float64 `json:"sd"`
+	Min    float64 `json:"min"`
+	P10    float64 `json:"p10"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+	Max    float64 `json:"max"`
+}
+
+// Quantile returns the p-quantile of a sorted sample, interpolating
+// linearly between order statistics.
+func Quantile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	h := p * float64(len(sorted)-1)
+	lo := int(math.Floor(h))
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (h-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+// Describe summarizes a sample. It returns the zero Summary for an empty
+// sample and does not modify values.
+func Describe(values []float64) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	mean := sum / float64(len(sorted))
+	var ss float64
+	for _, v := range sorted {
+		ss += (v - mean) * (v - mean)
+	}
+	var sd float64
+	if len(sorted) > 1 {
+		sd = math.Sqrt(ss / float64(len(sorted)-1))
+	}
+	return Summary{
+		N:      len(sorted),
+		Mean:   mean,
+		SD:     sd,
+		Min:    sorted[0],
+		P10:    Quantile(sorted, 0.10),
+		P25:    Quantile(sorted, 0.25),
+		Median: Quantile(sorted, 0.50),
+		P75:    Quantile(sorted, 0.75),
+		P90:    Quantile(sorted, 0.90),
+		Max:    sorted[len(sorted)-1],
+	}
+}
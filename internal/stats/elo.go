@@ -0,0 +1,16 @@
+package stats
+
+import "math"
+
+// EloInitial is the rating assigned to an option before any comparisons.
+const EloInitial = 1500.0
+
+// EloK is the update step used for pairwise poll comparisons.
+const EloK = 32.0
+
+// EloUpdate returns the new ratings of a winner and loser after one match.
+func EloUpdate(winner, loser float64) (float64, float64) {
+	expected := 1 / (1 + math.Pow(10, (loser-winner)/400))
+	delta := EloK * (1 - expected)
+	return winner + delta, loser - delta
+}
@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func near(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol
+}
+
+func TestEloUpdateEqualRatings(t *testing.T) {
+	w, l := EloUpdate(1500, 1500)
+	if w != 1516 || l != 1484 {
+		t.Errorf("EloUpdate(1500, 1500) = %v, %v; want 1516, 1484", w, l)
+	}
+}
+
+func TestEloUpdateRewardsUpsets(t *testing.T) {
+	favWin, _ := EloUpdate(1900, 1500)
+	underWin, _ := EloUpdate(1500, 1900)
+	if !near(favWin, 1902.9091, 1e-4) {
+		t.Errorf("favourite rises to %v, want 1902.9091", favWin)
+	}
+	if !near(underWin, 1529.0909, 1e-4) {
+		t.Errorf("underdog rises to %v, want 1529.0909", underWin)
+	}
+
+	// Whatever the outcome, the points won equal the points lost.
+	for _, pair := range [][2]float64{{1900, 1500}, {1500, 1900}, {1234, 1777}} {
+		w, l := EloUpdate(pair[0], pair[1])
+		if !near(w+l, pair[0]+pair[1], 1e-9) {
+			t.Errorf("EloUpdate(%v, %v) = %v, %v; total not conserved", pair[0], pair[1], w, l)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrSingular is returned when a matrix cannot be inverted.
+var ErrSingular = errors.New("stats: matrix is singular")
+
+// Invert returns the inverse of the square matrix a using Gauss-Jordan
+// elimination with partial pivoting. The input is left unchanged.
+func Invert(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	aug := make([][]float64, n)
+	for i := range a {
+		aug[i] = make([]float64, 2*n)
+		copy(aug[i], a[i])
+		aug[i][n+i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(aug[row][col]) > math.Abs(aug[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(aug[pivot][col]) < 1e-12 {
+			return nil, ErrSingular
+		}
+		aug[col], aug[pivot] = aug[pivot], aug[col]
+
+		scale := aug[col][col]
+		for j := range aug[col] {
+			aug[col][j] /= scale
+		}
+		for row := 0; row < n; row++ {
+			if row == col || aug[row][col] == 0 {
+				continue
+			}
+			factor := aug[row][col]
+			for j := range aug[row] {
+				aug[row][j] -= factor * aug[col][j]
+			}
+		}
+	}
+
+	inv := make([][]float64, n)
+	for i := range aug {
+		inv[i] = aug[i][n:]
+	}
+	return inv, nil
+}
@@ -0,0 +1,43 @@
+package stats
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrInvalidDesign is returned for proportions, alpha or power outside (0, 1)
+// or an effect of zero.
+var ErrInvalidDesign = errors.New("stats: invalid design parameters")
+
+func validProb(p float64) bool { return p > 0 && p < 1 }
+
+// SampleSizeTwoProportions returns the participants needed per group for a
+// two-sided z-test to detect p1 vs p2 at the given alpha and power:
+//
+//	n = (z_{1-a/2} sqrt(2 pbar (1-pbar)) + z_{power} sqrt(p1 q1 + p2 q2))^2 / (p1 - p2)^2
+func SampleSizeTwoProportions(p1, p2, alpha, power float64) (int, error) {
+	if !validProb(p1) || !validProb(p2) || !validProb(alpha) || !validProb(power) || p1 == p2 {
+		return 0, ErrInvalidDesign
+	}
+	pbar := (p1 + p2) / 2
+	za := NormalQuantile(1 - alpha/2)
+	zb := NormalQuantile(power)
+	num := za*math.Sqrt(2*pbar*(1-pbar)) + zb*math.Sqrt(p1*(1-p1)+p2*(1-p2))
+	return int(math.Ceil(num * num / ((p1 - p2) * (p1 - p2)))), nil
+}
+
+// PowerTwoProportions returns the power of a two-sided z-test with n1 and
+// n2 participants to detect p1 vs p2, ignoring the negligible far tail.
+func PowerTwoProportions(p1, p2 float64, n1, n2 int, alpha float64) (float64, error) {
+	if !validProb(p1) || !validProb(p2) || !validProb(alpha) || p1 == p2 {
+		return 0, ErrInvalidDesign
+	}
+	if n1 == 0 || n2 == 0 {
+		return 0, nil
+	}
+	pbar := (p1*float64(n1) + p2*float64(n2)) / float64(n1+n2)
+	seNull := math.Sqrt(pbar * (1 - pbar) * (1/float64(n1) + 1/float64(n2)))
+	seAlt := math.Sqrt(p1*(1-p1)/float64(n1) + p2*(1-p2)/float64(n2))
+	za := NormalQuantile(1 - alpha/2)
+	return NormalCDF((math.Abs(p1-p2) - za*seNull) / seAlt), nil
+}
@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSampleSizeTwoProportions(t *testing.T) {
+	n, err := SampleSizeTwoProportions(0.5, 0.6, 0.05, 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 388 {
+		t.Errorf("n = %d, want 388 per group", n)
+	}
+	if swapped, _ := SampleSizeTwoProportions(0.6, 0.5, 0.05, 0.8); swapped != n {
+		t.Errorf("swapping groups gave %d, want %d", swapped, n)
+	}
+	if more, _ := SampleSizeTwoProportions(0.5, 0.6, 0.05, 0.9); more != 519 {
+		t.Errorf("power 0.9 needs %d, want 519", more)
+	}
+}
+
+func TestSampleSizeRejectsInvalidDesigns(t *testing.T) {
+	designs := [][4]float64{
+		{0.5, 0.5, 0.05, 0.8}, // no effect to detect
+		{0, 0.5, 0.05, 0.8},
+		{0.5, 0.6, 1, 0.8},
+	}
+	for _, d := range designs {
+		if _, err := SampleSizeTwoProportions(d[0], d[1], d[2], d[3]); !errors.Is(err, ErrInvalidDesign) {
+			t.Errorf("SampleSizeTwoProportions%v err = %v, want %v", d, err, ErrInvalidDesign)
+		}
+	}
+}
+
+func TestPowerTwoProportions(t *testing.T) {
+	// The sample size above is the smallest that reaches 80% power.
+	if p, err := PowerTwoProportions(0.5, 0.6, 388, 388, 0.05); err != nil || !near(p, 0.80, 0.005) {
+		t.Errorf("power at 388 per group = %v, %v; want about 0.80", p, err)
+	}
+	if p, err := PowerTwoProportions(0.5, 0.6, 100, 100, 0.05); err != nil || !near(p, 0.294, 0.001) {
+		t.Errorf("power at 100 per group = %v, %v; want about 0.294", p, err)
+	}
+	if p, err := PowerTwoProportions(0.5, 0.6, 0, 100, 0.05); err != nil || p != 0 {
+		t.Errorf("power with an empty group = %v, %v; want 0", p, err)
+	}
+	if _, err := PowerTwoProportions(0.5, 0.5, 10, 10, 0.05); !errors.Is(err, ErrInvalidDesign) {
+		t.Errorf("equal proportions: err = %v, want %v", err, ErrInvalidDesign)
+	}
+}
@@ -0,0 +1,100 @@
+package stats
+
+import "math"
+
+// OLSResult holds least-squares coefficients with cluster-robust standard
+// errors.
+type OLSResult struct {
+	Coef     []float64
+	StdErr   []float64
+	N        int
+	Clusters int
+}
+
+// ClusteredOLS regresses y on the rows of x by ordinary least squares and
+// computes the CR1 cluster-robust (sandwich) covariance, treating rows with
+// the same cluster id as correlated:
+//
+//	V = c (X'X)^-1 (sum_g X_g' u_g u_g' X_g) (X'X)^-1
+//	c = G/(G-1) * (N-1)/(N-K)
+func ClusteredOLS(x [][]float64, y []float64, clusters []int) (OLSResult, error) {
+	n := len(x)
+	if n == 0 || len(y) != n || len(clusters) != n {
+		return OLSResult{}, ErrInsufficientData
+	}
+	k := len(x[0])
+	if n <= k {
+		return OLSResult{}, ErrInsufficientData
+	}
+
+	xtx := make([][]float64, k)
+	for i := range xtx {
+		xtx[i] = make([]float64, k)
+	}
+	xty := make([]float64, k)
+	for r, row := range x {
+		for i := 0; i < k; i++ {
+			xty[i] += row[i] * y[r]
+			for j := 0; j < k; j++ {
+				xtx[i][j] += row[i] * row[j]
+			}
+		}
+	}
+	bread, err := Invert(xtx)
+	if err != nil {
+		return OLSResult{}, err
+	}
+	coef := make([]float64, k)
+	for i := 0; i < k; i++ {
+		for j := 0; j < k; j++ {
+			coef[i] += bread[i][j] * xty[j]
+		}
+	}
+
+	// Per-cluster score sums X_g' u_g
+	scores := map[int][]float64{}
+	for r, row := range x {
+		var fitted float64
+		for i := 0; i < k; i++ {
+			fitted += row[i] * coef[i]
+		}
+		u := y[r] - fitted
+		s := scores[clusters[r]]
+		if s == nil {
+			s = make([]float64, k)
+			scores[clusters[r]] = s
+		}
+		for i := 0; i < k; i++ {
+			s[i] += row[i] * u
+		}
+	}
+	g := len(scores)
+	if g < 2 {
+		return OLSResult{}, ErrInsufficientData
+	}
+	meat := make([][]float64, k)
+	for i := range meat {
+		meat[i] = make([]float64, k)
+	}
+	for _, s := range scores {
+		for i := 0; i < k; i++ {
+			for j := 0; j < k; j++ {
+				meat[i][j] += s[i] * s[j]
+			}
+		}
+	}
+
+	scale := float64(g) / float64(g-1) * float64(n-1) / float64(n-k)
+	stdErr := make([]float64, k)
+	for i := 0; i < k; i++ {
+		// Diagonal of bread * meat * bread
+		var v float64
+		for a := 0; a < k; a++ {
+			for b := 0; b < k; b++ {
+				v += bread[i][a] * meat[a][b] * bread[b][i]
+			}
+		}
+		stdErr[i] = math.Sqrt(math.Max(0, scale*v))
+	}
+	return OLSResult{Coef: coef, StdErr: stdErr, N: n, Clusters: g}, nil
+}
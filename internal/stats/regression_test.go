@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestClusteredOLSExactFit(t *testing.T) {
+	got, err := ClusteredOLS(
+		[][]float64{{1, 0}, {1, 1}, {1, 2}, {1, 3}},
+		[]float64{1, 3, 5, 7},
+		[]int{1, 2, 3, 4},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(got.Coef[0], 1, 1e-9) || !near(got.Coef[1], 2, 1e-9) {
+		t.Errorf("coef = %v, want [1 2]", got.Coef)
+	}
+	if !near(got.StdErr[0], 0, 1e-9) || !near(got.StdErr[1], 0, 1e-9) {
+		t.Errorf("stderr = %v, want zeros for an exact fit", got.StdErr)
+	}
+}
+
+func TestClusteredOLSMean(t *testing.T) {
+	ones := [][]float64{{1}, {1}, {1}, {1}}
+
+	// With one row per cluster and an intercept only, CR1 reduces to the
+	// usual standard error of the mean, sd / sqrt(n).
+	single, err := ClusteredOLS(ones, []float64{1, 2, 3, 4}, []int{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(single.Coef[0], 2.5, 1e-9) || !near(single.StdErr[0], math.Sqrt(5.0/3)/2, 1e-9) {
+		t.Errorf("singleton clusters: coef %v, stderr %v", single.Coef, single.StdErr)
+	}
+
+	// Two clusters of identical pairs: each cluster's score is doubled, so
+	// V = 2/1 * 3/3 * (2*1.5)^2*2 / 16.
+	paired, err := ClusteredOLS(ones, []float64{1, 1, 4, 4}, []int{1, 1, 2, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(paired.Coef[0], 2.5, 1e-9) || !near(paired.StdErr[0], 1.5, 1e-9) {
+		t.Errorf("paired clusters: coef %v, stderr %v", paired.Coef, paired.StdErr)
+	}
+}
+
+func TestClusteredOLSErrors(t *testing.T) {
+	if _, err := ClusteredOLS([][]float64{{1}, {1}, {1}}, []float64{1, 2, 3}, []int{1, 1, 1}); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("single cluster: err = %v, want %v", err, ErrInsufficientData)
+	}
+	if _, err := ClusteredOLS([][]float64{{1, 2}}, []float64{1}, []int{1}); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("fewer rows than columns: err = %v, want %v", err, ErrInsufficientData)
+	}
+	if _, err := ClusteredOLS([][]float64{{1, 2}, {2, 4}, {3, 6}}, []float64{1, 2, 3}, []int{1, 2, 3}); !errors.Is(err, ErrSingular) {
+		t.Errorf("collinear columns: err = %v, want %v", err, ErrSingular)
+	}
+}
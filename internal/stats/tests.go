@@ -0,0 +1,141 @@
+package stats
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrInsufficientData is returned when a test has too little data to run.
+var ErrInsufficientData = errors.New("stats: insufficient data")
+
+// ChiSquareResult is the outcome of a chi-square test of independence.
+type ChiSquareResult struct {
+	Statistic float64 `json:"statistic"`
+	DF        int     `json:"df"`
+	PValue    float64 `json:"p_value"`
+	CramersV  float64 `json:"cramers_v"`
+}
+
+// ChiSquareIndependence tests whether rows and columns of a contingency
+// table are independent. Rows or columns with no observations are ignored.
+func ChiSquareIndependence(table [][]float64) (ChiSquareResult, error) {
+	var rows []int
+	colTotals := map[int]float64{}
+	rowTotals := map[int]float64{}
+	var total float64
+	for i, row := range table {
+		for j, v := range row {
+			rowTotals[i] += v
+			colTotals[j] += v
+			total += v
+		}
+		if rowTotals[i] > 0 {
+			rows = append(rows, i)
+		}
+	}
+	var cols []int
+	for j := range colTotals {
+		if colTotals[j] > 0 {
+			cols = append(cols, j)
+		}
+	}
+	if len(rows) < 2 || len(cols) < 2 {
+		return ChiSquareResult{}, ErrInsufficientData
+	}
+
+	var stat float64
+	for _, i := range rows {
+		for _, j := range cols {
+			var observed float64
+			if j < len(table[i]) {
+				observed = table[i][j]
+			}
+			expected := rowTotals[i] * colTotals[j] / total
+			stat += (observed - expected) * (observed - expected) / expected
+		}
+	}
+	df := (len(rows) - 1) * (len(cols) - 1)
+	minDim := math.Min(float64(len(rows)), float64(len(cols))) - 1
+	return ChiSquareResult{
+		Statistic: stat,
+		DF:        df,
+		PValue:    ChiSquareSurvival(stat, float64(df)),
+		CramersV:  math.Sqrt(stat / (total * minDim)),
+	}, nil
+}
+
+// ProportionTestResult is the outcome of a two-proportion z-test comparing
+// group 2 against group 1.
+type ProportionTestResult struct {
+	Rate1      float64 `json:"rate_1"`
+	Rate2      float64 `json:"rate_2"`
+	Difference float64 `json:"difference"`
+	Z          float64 `json:"z"`
+	PValue     float64 `json:"p_value"`
+	Lower      float64 `json:"ci_lower"`
+	Upper      float64 `json:"ci_upper"`
+	CohensH    float64 `json:"cohens_h"`
+}
+
+// TwoProportionTest runs a two-sided z-test of x1/n1 against x2/n2. The test
+// uses the pooled standard error; the (1-alpha) confidence interval for the
+// difference uses the unpooled one.
+func TwoProportionTest(x1, n1, x2, n2, alpha float64) (ProportionTestResult, error) {
+	if n1 == 0 || n2 == 0 {
+		return ProportionTestResult{}, ErrInsufficientData
+	}
+	p1, p2 := x1/n1, x2/n2
+	res := ProportionTestResult{
+		Rate1:      p1,
+		Rate2:      p2,
+		Difference: p2 - p1,
+		CohensH:    2*math.Asin(math.Sqrt(p2)) - 2*math.Asin(math.Sqrt(p1)),
+		PValue:     1,
+	}
+
+	pooled := (x1 + x2) / (n1 + n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/n1 + 1/n2))
+	if se > 0 {
+		res.Z = res.Difference / se
+		res.PValue = 2 * (1 - NormalCDF(math.Abs(res.Z)))
+	}
+
+	z := NormalQuantile(1 - alpha/2)
+	seDiff := math.Sqrt(p1*(1-p1)/n1 + p2*(1-p2)/n2)
+	res.Lower = res.Difference - z*seDiff
+	res.Upper = res.Difference + z*seDiff
+	return res, nil
+}
+
+// ChiSquareGoodnessOfFit tests observed counts against expected proportions,
+// which are normalized to sum to one. CramersV reports the effect size
+// sqrt(stat / (n * (k - 1))).
+func ChiSquareGoodnessOfFit(observed, expected []float64) (ChiSquareResult, error) {
+	if len(observed) < 2 || len(observed) != len(expected) {
+		return ChiSquareResult{}, ErrInsufficientData
+	}
+	var n, sum float64
+	for i := range observed {
+		n += observed[i]
+		sum += expected[i]
+	}
+	if n == 0 || sum <= 0 {
+		return ChiSquareResult{}, ErrInsufficientData
+	}
+
+	var stat float64
+	for i, o := range observed {
+		e := n * expected[i] / sum
+		if e <= 0 {
+			return ChiSquareResult{}, ErrInsufficientData
+		}
+		stat += (o - e) * (o - e) / e
+	}
+	df := len(observed) - 1
+	return ChiSquareResult{
+		Statistic: stat,
+		DF:        df,
+		PValue:    ChiSquareSurvival(stat, float64(df)),
+		CramersV:  math.Sqrt(stat / (n * float64(df))),
+	}, nil
+}
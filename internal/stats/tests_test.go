@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChiSquareIndependence(t *testing.T) {
+	got, err := ChiSquareIndependence([][]float64{{10, 20}, {20, 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.DF != 1 || !near(got.Statistic, 20.0/3, 1e-9) || !near(got.PValue, 0.0098233, 1e-6) || !near(got.CramersV, 1.0/3, 1e-9) {
+		t.Errorf("got %+v, want statistic 20/3, df 1, p 0.0098233, V 1/3", got)
+	}
+
+	// Empty rows and columns carry no information and are dropped.
+	padded, err := ChiSquareIndependence([][]float64{{10, 0, 20}, {0, 0, 0}, {20, 0, 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if padded.DF != got.DF || !near(padded.Statistic, got.Statistic, 1e-9) {
+		t.Errorf("padded table gave %+v, want %+v", padded, got)
+	}
+
+	proportional, err := ChiSquareIndependence([][]float64{{10, 20}, {20, 40}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proportional.Statistic != 0 || !near(proportional.PValue, 1, 1e-9) {
+		t.Errorf("proportional rows gave %+v, want statistic 0 and p 1", proportional)
+	}
+}
+
+func TestChiSquareIndependenceNeedsTwoByTwo(t *testing.T) {
+	for _, table := range [][][]float64{{{1, 2}}, {{1}, {2}}} {
+		if _, err := ChiSquareIndependence(table); !errors.Is(err, ErrInsufficientData) {
+			t.Errorf("ChiSquareIndependence(%v) err = %v, want %v", table, err, ErrInsufficientData)
+		}
+	}
+}
+
+func TestTwoProportionTest(t *testing.T) {
+	up, err := TwoProportionTest(50, 100, 60, 100, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(up.Z, 1.4213381, 1e-6) || !near(up.PValue, 0.1552185, 1e-6) {
+		t.Errorf("z = %v, p = %v; want 1.4213381, 0.1552185", up.Z, up.PValue)
+	}
+	if up.Lower > up.Difference || up.Upper < up.Difference {
+		t.Errorf("interval [%v, %v] excludes difference %v", up.Lower, up.Upper, up.Difference)
+	}
+
+	down, err := TwoProportionTest(60, 100, 50, 100, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(down.Z, -up.Z, 1e-12) || !near(down.PValue, up.PValue, 1e-12) {
+		t.Errorf("swapping groups gave z = %v, p = %v", down.Z, down.PValue)
+	}
+
+	// Identical rates, including the degenerate all-success case, show no effect.
+	for _, x := range []float64{5, 10} {
+		same, err := TwoProportionTest(x, 10, x, 10, 0.05)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if same.Z != 0 || same.PValue != 1 {
+			t.Errorf("%v/10 vs %v/10: z = %v, p = %v; want 0, 1", x, x, same.Z, same.PValue)
+		}
+	}
+
+	if _, err := TwoProportionTest(0, 0, 5, 10, 0.05); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("empty group err = %v, want %v", err, ErrInsufficientData)
+	}
+}
+
+func TestChiSquareGoodnessOfFit(t *testing.T) {
+	got, err := ChiSquareGoodnessOfFit([]float64{30, 10}, []float64{1, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(got.Statistic, 10, 1e-9) || !near(got.CramersV, 0.5, 1e-9) {
+		t.Errorf("got %+v, want statistic 10 and V 0.5", got)
+	}
+	// Expected shares are normalized, so only their ratio matters.
+	scaled, err := ChiSquareGoodnessOfFit([]float64{30, 10}, []float64{3, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(scaled.Statistic, got.Statistic, 1e-9) {
+		t.Errorf("scaled expectations gave statistic %v, want %v", scaled.Statistic, got.Statistic)
+	}
+
+	for _, bad := range [][2][]float64{
+		{{0, 0}, {1, 1}},
+		{{1, 2}, {1}},
+		{{1, 2}, {1, 0}},
+	} {
+		if _, err := ChiSquareGoodnessOfFit(bad[0], bad[1]); !errors.Is(err, ErrInsufficientData) {
+			t.Errorf("ChiSquareGoodnessOfFit(%v, %v) err = %v, want %v", bad[0], bad[1], err, ErrInsufficientData)
+		}
+	}
+}
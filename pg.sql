@@ -6,14 +6,35 @@ CREATE TABLE polls (
     description TEXT,
     created_by TEXT NOT NULL,
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-    sharable_link TEXT UNIQUE NOT NULL
+    sharable_link TEXT UNIQUE NOT NULL,
+    mode TEXT NOT NULL DEFAULT 'standard', -- 'standard', 'pairwise' or 'randomized_response'
+    phase TEXT NOT NULL DEFAULT 'voting', -- 'submission' or 'voting'
+    survey_id UUID, -- set when the poll is a survey question
+    influence_conditions TEXT NOT NULL DEFAULT '', -- e.g. 'visible,blind'
+    shuffle_options BOOLEAN NOT NULL DEFAULT FALSE, -- per-respondent option order
+    max_response_seconds INTEGER NOT NULL DEFAULT 0, -- 0 means no limit
+    truth_probability DOUBLE PRECISION NOT NULL DEFAULT 0, -- randomized response design
+    forced_yes_probability DOUBLE PRECISION NOT NULL DEFAULT 0,
+    instruction_key TEXT NOT NULL DEFAULT '', -- secret keying each respondent's instruction
+    privacy_budget DOUBLE PRECISION NOT NULL DEFAULT 0, -- total epsilon; 0 disables noisy releases
+    privacy_epsilon DOUBLE PRECISION NOT NULL DEFAULT 0, -- spent per release
+    privacy_delta DOUBLE PRECISION NOT NULL DEFAULT 0,
+    privacy_mechanism TEXT NOT NULL DEFAULT '', -- 'laplace' or 'gaussian'
+    max_votes INTEGER NOT NULL DEFAULT 0, -- auto-close target; 0 disables
+    closed_at TIMESTAMP, -- no votes accepted once set
+    correct_option_id UUID, -- set on attention-check polls
+    recurring_poll_id UUID -- set on instances of a recurring poll
 );
 
 -- Poll options
 CREATE TABLE poll_options (
     id UUID PRIMARY KEY,
     poll_id UUID REFERENCES polls(id),
-    option_text TEXT NOT NULL
+    option_text TEXT NOT NULL,
+    submitted_by UUID REFERENCES users(id), -- set for participant-submitted options
+    write_in BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0, -- display order within the poll
+    seed_count INTEGER NOT NULL DEFAULT 0 -- shown on top of real votes in the 'seeded' condition
 );
 
 -- Users table
@@ -28,5 +49,381 @@ CREATE TABLE votes (
     poll_id UUID REFERENCES polls(id),
     option_id UUID REFERENCES poll_options(id),
     user_id UUID REFERENCES users(id),
-    voted_at TIMESTAMP NOT NULL DEFAULT NOW()
-);
\ No newline at end of file
+    voted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    condition TEXT NOT NULL DEFAULT '', -- result-visibility condition shown to the voter
+    displayed_position INTEGER, -- where the chosen option appeared, 0-based
+    first_fetched_at TIMESTAMP, -- paradata, NULL for votes cast without it
+    latency_ms BIGINT,
+    selection_changes INTEGER,
+    referred_by UUID REFERENCES users(id) -- owner of the share link the voter arrived through
+);
+
+-- Pairwise comparisons (one row per head-to-head choice)
+CREATE TABLE pairwise_comparisons (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    winner_id UUID REFERENCES poll_options(id),
+    loser_id UUID REFERENCES poll_options(id),
+    user_id UUID REFERENCES users(id),
+    compared_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Bradley-Terry scores computed by the worker
+CREATE TABLE pairwise_scores (
+    poll_id UUID REFERENCES polls(id),
+    option_id UUID REFERENCES poll_options(id),
+    score DOUBLE PRECISION NOT NULL,
+    std_err DOUBLE PRECISION NOT NULL,
+    lower DOUBLE PRECISION NOT NULL,
+    upper DOUBLE PRECISION NOT NULL,
+    comparisons INTEGER NOT NULL DEFAULT 0,
+    computed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (poll_id, option_id)
+);
+
+-- Options proposed by participants, pending owner moderation
+CREATE TABLE option_submissions (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    submitted_by UUID REFERENCES users(id),
+    text TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'approved' or 'rejected'
+    option_id UUID REFERENCES poll_options(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    reviewed_at TIMESTAMP
+);
+
+-- Free text supplied with write-in options
+CREATE TABLE write_in_answers (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    option_id UUID REFERENCES poll_options(id),
+    vote_id UUID REFERENCES votes(id),
+    user_id UUID REFERENCES users(id),
+    text TEXT NOT NULL,
+    normalized TEXT NOT NULL,
+    promoted_option_id UUID REFERENCES poll_options(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX write_in_answers_poll_normalized_idx ON write_in_answers (poll_id, normalized);
+
+-- Surveys group ordered question polls
+CREATE TABLE surveys (
+    id UUID PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE survey_questions (
+    id UUID PRIMARY KEY,
+    survey_id UUID REFERENCES surveys(id),
+    poll_id UUID REFERENCES polls(id),
+    position INTEGER NOT NULL,
+    UNIQUE (survey_id, position)
+);
+
+-- One response session per participant and survey
+CREATE TABLE survey_responses (
+    id UUID PRIMARY KEY,
+    survey_id UUID REFERENCES surveys(id),
+    user_id UUID REFERENCES users(id),
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    submitted_at TIMESTAMP,
+    UNIQUE (survey_id, user_id)
+);
+
+-- Draft answers, written out as votes on submit
+CREATE TABLE survey_answers (
+    id UUID PRIMARY KEY,
+    response_id UUID REFERENCES survey_responses(id),
+    poll_id UUID REFERENCES polls(id),
+    option_id UUID REFERENCES poll_options(id),
+    answered_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (response_id, poll_id)
+);
+
+-- Skip and display rules between survey questions
+CREATE TABLE branch_rules (
+    id UUID PRIMARY KEY,
+    survey_id UUID REFERENCES surveys(id),
+    source_poll_id UUID REFERENCES polls(id),
+    operator TEXT NOT NULL, -- 'equals', 'score_gte' or 'score_lte'
+    option_id UUID REFERENCES poll_options(id),
+    threshold DOUBLE PRECISION,
+    action TEXT NOT NULL, -- 'skip_to' or 'show_if'
+    target_poll_id UUID REFERENCES polls(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Experiments randomize participants across variant polls
+CREATE TABLE experiments (
+    id UUID PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    randomization TEXT NOT NULL DEFAULT 'hash', -- 'hash' or 'block'
+    block_size INTEGER NOT NULL DEFAULT 0,
+    stratify_by TEXT NOT NULL DEFAULT '', -- comma-separated: 'country', 'age_group'
+    target_per_arm INTEGER NOT NULL DEFAULT 0 -- planned sample per arm
+);
+
+CREATE TABLE experiment_arms (
+    id UUID PRIMARY KEY,
+    experiment_id UUID REFERENCES experiments(id),
+    poll_id UUID UNIQUE REFERENCES polls(id),
+    name TEXT NOT NULL,
+    weight DOUBLE PRECISION NOT NULL DEFAULT 1,
+    position INTEGER NOT NULL
+);
+
+CREATE TABLE experiment_assignments (
+    id UUID PRIMARY KEY,
+    experiment_id UUID REFERENCES experiments(id),
+    arm_id UUID REFERENCES experiment_arms(id),
+    user_id UUID REFERENCES users(id),
+    stratum TEXT NOT NULL DEFAULT '',
+    assigned_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (experiment_id, user_id)
+);
+
+-- Current permuted block per stratum; rows are locked while a slot is drawn
+CREATE TABLE experiment_blocks (
+    id UUID PRIMARY KEY,
+    experiment_id UUID REFERENCES experiments(id),
+    stratum TEXT NOT NULL DEFAULT '',
+    block_number INTEGER NOT NULL DEFAULT 0,
+    sequence TEXT NOT NULL DEFAULT '', -- comma-separated arm ids
+    next INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (experiment_id, stratum)
+);
+
+-- Result-visibility condition assigned to each participant of a poll
+CREATE TABLE result_exposures (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    user_id UUID REFERENCES users(id),
+    condition TEXT NOT NULL,
+    shown_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (poll_id, user_id)
+);
+
+-- First fetch of a poll by each participant, the start of response time
+CREATE TABLE poll_views (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    user_id UUID REFERENCES users(id),
+    first_fetched_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    referred_by UUID REFERENCES users(id), -- first share link followed
+    UNIQUE (poll_id, user_id)
+);
+
+-- Noisy publications of poll counts; their epsilons sum to the budget spent
+CREATE TABLE privacy_releases (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    mechanism TEXT NOT NULL,
+    epsilon DOUBLE PRECISION NOT NULL,
+    delta DOUBLE PRECISION NOT NULL DEFAULT 0,
+    total_votes BIGINT NOT NULL, -- true count the release was computed from
+    counts TEXT NOT NULL, -- 'option_id=count' pairs
+    released_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Conjoint studies: respondents choose between randomized attribute profiles
+CREATE TABLE conjoint_studies (
+    id UUID PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    design TEXT NOT NULL DEFAULT 'full', -- 'full' or 'fractional'
+    fraction_size INTEGER NOT NULL DEFAULT 0,
+    fraction TEXT NOT NULL DEFAULT '', -- ';'-separated profiles of comma-separated level indices, drawn at creation
+    tasks_per_respondent INTEGER NOT NULL
+);
+
+CREATE TABLE conjoint_attributes (
+    id UUID PRIMARY KEY,
+    study_id UUID REFERENCES conjoint_studies(id),
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    UNIQUE (study_id, position)
+);
+
+-- The first level of each attribute is the baseline
+CREATE TABLE conjoint_levels (
+    id UUID PRIMARY KEY,
+    attribute_id UUID REFERENCES conjoint_attributes(id),
+    label TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    UNIQUE (attribute_id, position)
+);
+
+CREATE TABLE conjoint_tasks (
+    id UUID PRIMARY KEY,
+    study_id UUID REFERENCES conjoint_studies(id),
+    user_id UUID REFERENCES users(id),
+    task_number INTEGER NOT NULL,
+    profile_a TEXT NOT NULL, -- comma-separated level ids in attribute order
+    profile_b TEXT NOT NULL,
+    chosen INTEGER, -- 0 or 1; NULL until answered
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    answered_at TIMESTAMP,
+    UNIQUE (study_id, user_id, task_number)
+);
+
+-- AMCE per level, replaced by the worker on every fit
+CREATE TABLE conjoint_estimates (
+    id UUID PRIMARY KEY,
+    study_id UUID REFERENCES conjoint_studies(id),
+    attribute_id UUID REFERENCES conjoint_attributes(id),
+    level_id UUID REFERENCES conjoint_levels(id),
+    baseline BOOLEAN NOT NULL DEFAULT FALSE,
+    estimate DOUBLE PRECISION NOT NULL,
+    std_err DOUBLE PRECISION NOT NULL,
+    lower DOUBLE PRECISION NOT NULL,
+    upper DOUBLE PRECISION NOT NULL,
+    observations INTEGER NOT NULL,
+    respondents INTEGER NOT NULL,
+    computed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Pre-registered plans; commitment is the hex SHA-256 of document
+CREATE TABLE preregistrations (
+    id UUID PRIMARY KEY,
+    poll_id UUID UNIQUE REFERENCES polls(id),
+    experiment_id UUID UNIQUE REFERENCES experiments(id),
+    created_by TEXT NOT NULL,
+    document TEXT NOT NULL, -- canonical JSON exactly as hashed
+    commitment TEXT NOT NULL,
+    committed_at TIMESTAMP NOT NULL,
+    locked_at TIMESTAMP -- set by the first vote
+);
+
+-- Versioned consent statements; the highest version is current
+CREATE TABLE consent_texts (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    version INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (poll_id, version)
+);
+
+CREATE TABLE consents (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    user_id UUID REFERENCES users(id),
+    consent_text_id UUID REFERENCES consent_texts(id),
+    version INTEGER NOT NULL,
+    accepted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (consent_text_id, user_id)
+);
+
+-- Eligibility rules checked before a participant may vote
+CREATE TABLE screeners (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    kind TEXT NOT NULL, -- 'question', 'country' or 'completed_poll'
+    question TEXT NOT NULL DEFAULT '',
+    options TEXT NOT NULL DEFAULT '', -- comma-separated
+    accept TEXT NOT NULL DEFAULT '', -- comma-separated accepted answers
+    countries TEXT NOT NULL DEFAULT '', -- comma-separated ISO codes
+    required_poll_id UUID REFERENCES polls(id),
+    position INTEGER NOT NULL
+);
+
+-- Screening outcomes, kept apart from votes to report screen-out rates
+CREATE TABLE screening_results (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    user_id UUID REFERENCES users(id),
+    eligible BOOLEAN NOT NULL,
+    failed_screener_id UUID, -- first screener the participant failed
+    answers TEXT NOT NULL DEFAULT '{}', -- JSON of screener id to answer
+    screened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (poll_id, user_id)
+);
+
+-- Longitudinal panels: fixed members answering repeated waves of a poll
+CREATE TABLE panels (
+    id UUID PRIMARY KEY,
+    title TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    source_poll_id UUID REFERENCES polls(id)
+);
+
+CREATE TABLE panel_members (
+    id UUID PRIMARY KEY,
+    panel_id UUID REFERENCES panels(id),
+    user_id UUID REFERENCES users(id),
+    joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (panel_id, user_id)
+);
+
+CREATE TABLE panel_waves (
+    id UUID PRIMARY KEY,
+    panel_id UUID REFERENCES panels(id),
+    poll_id UUID UNIQUE REFERENCES polls(id), -- this wave's copy of the source poll
+    wave_number INTEGER NOT NULL,
+    opens_at TIMESTAMP NOT NULL,
+    invited_at TIMESTAMP, -- set once invitations are queued
+    UNIQUE (panel_id, wave_number)
+);
+
+-- Recurring poll templates instantiated by the worker on a cron schedule
+CREATE TABLE recurring_polls (
+    id UUID PRIMARY KEY,
+    website_id TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    options TEXT NOT NULL, -- comma-separated
+    cronspec TEXT NOT NULL, -- e.g. 'CRON_TZ=Europe/Berlin 0 9 * * 1-5'
+    link_base TEXT NOT NULL,
+    notify BOOLEAN NOT NULL DEFAULT FALSE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_run_at TIMESTAMP
+);
+
+CREATE TABLE recurring_subscriptions (
+    id UUID PRIMARY KEY,
+    recurring_poll_id UUID REFERENCES recurring_polls(id),
+    user_id UUID REFERENCES users(id),
+    subscribed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (recurring_poll_id, user_id)
+);
+
+-- Personal share links; the token is passed as ?ref= when opening the poll
+CREATE TABLE share_tokens (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    user_id UUID REFERENCES users(id),
+    token TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (poll_id, user_id)
+);
+
+-- Personal invitations to a poll and their delivery status
+CREATE TABLE invitations (
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id),
+    identifier TEXT NOT NULL, -- email or phone number
+    channel TEXT NOT NULL, -- 'email' or 'sms'
+    token TEXT UNIQUE NOT NULL,
+    link TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP,
+    opened_at TIMESTAMP,
+    voted_at TIMESTAMP,
+    reminders INTEGER NOT NULL DEFAULT 0,
+    last_reminded_at TIMESTAMP,
+    UNIQUE (poll_id, identifier)
+);